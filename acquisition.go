@@ -0,0 +1,35 @@
+package tcppool
+
+import (
+	"net"
+
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// Acquisition represents a connection being acquired in the background by GetAsync.
+// It behaves like a future: the caller can wait on Done, collect the connection with Result,
+// or give up with Cancel, in which case a late connection goes back to the pool.
+type Acquisition struct {
+	impl *internal.Acquisition
+}
+
+// Done returns a channel that is closed once the acquisition has completed.
+func (a *Acquisition) Done() <-chan struct{} {
+	return a.impl.Done()
+}
+
+// Result blocks until the acquisition completes and returns its outcome.
+// A connection returned by Result is owned by the caller and must be released.
+//
+// Returns:
+//   - A net.Conn representing the connection.
+//   - An error, if the acquisition failed or was cancelled.
+func (a *Acquisition) Result() (net.Conn, error) {
+	return a.impl.Result()
+}
+
+// Cancel abandons the acquisition. Any connection acquired but not yet taken with Result
+// is returned to the pool, and Result reports context.Canceled.
+func (a *Acquisition) Cancel() {
+	a.impl.Cancel()
+}
@@ -0,0 +1,36 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// AutoscaleConfig makes a pool adjust its connection limit between Min and Max while it runs.
+// Every Interval the autoscaler looks at the latency of the backend, measured as the average time
+// connections are held, at how long callers queued for a connection and at the share of the limit
+// checked out. It grows the limit while the pool is saturated, with callers queuing while every
+// connection is out, and the latency is healthy, and shrinks it when the backend slows down, so
+// the pool neither starves callers at peak nor piles more load onto a struggling backend. A pool
+// whose connections are all held for longer than the interval still grows.
+// See WithAutoscale.
+type AutoscaleConfig = internal.AutoscaleConfig
+
+// AutoscaleStats reports the state of an autoscaled pool in Stats.Autoscale.
+type AutoscaleStats = internal.AutoscaleStats
+
+// ScaleAlgorithm selects how an autoscaled pool adjusts its connection limit.
+type ScaleAlgorithm = internal.ScaleAlgorithm
+
+const (
+	// ScaleAIMD adds one connection per interval while the pool is saturated, and multiplies the
+	// limit by AutoscaleConfig.Decrease when the latency exceeds AutoscaleConfig.LatencyThreshold.
+	ScaleAIMD = internal.ScaleAIMD
+	// ScaleGradient needs no threshold: it learns the latency of the healthy backend and shrinks
+	// the limit as the latency rises past AutoscaleConfig.Tolerance times that baseline, growing it
+	// by about its square root while the pool is saturated.
+	ScaleGradient = internal.ScaleGradient
+)
+
+// Defaults applied to the unset fields of an AutoscaleConfig.
+const (
+	DefaultScaleInterval  = internal.DefaultScaleInterval
+	DefaultScaleDecrease  = internal.DefaultScaleDecrease
+	DefaultScaleTolerance = internal.DefaultScaleTolerance
+)
@@ -73,3 +73,32 @@ func NewPolynomialBackoff(exponent uint) backoff.Backoff {
 		Exponent: exponent,
 	}
 }
+
+// BackoffFactory builds a backoff strategy from the named parameters found in a configuration
+// file or environment variables.
+type BackoffFactory = backoff.Factory
+
+// RegisterBackoff makes a backoff strategy selectable by name in configuration files and
+// environment variables. The built-in strategies are registered as "exponential" (base, max_delay),
+// "fibonacci" (max_delay), "fixed" (interval), "linear" (scalar) and "polynomial" (exponent).
+// To be serialized back by Config.MarshalJSON, a custom strategy must implement backoff.Describer.
+//
+// Parameters:
+//   - name: The name used to select the strategy.
+//   - factory: Builds the strategy from its parameters.
+func RegisterBackoff(name string, factory BackoffFactory) {
+	backoff.Register(name, factory)
+}
+
+// NewBackoff builds the backoff strategy registered under name.
+//
+// Parameters:
+//   - name: The registered strategy name.
+//   - params: The strategy's parameters, in seconds where they are durations.
+//
+// Returns:
+//   - The backoff strategy.
+//   - An error, if no strategy is registered under name or the parameters are rejected.
+func NewBackoff(name string, params map[string]uint) (backoff.Backoff, error) {
+	return backoff.New(name, params)
+}
@@ -52,3 +52,56 @@ func NewConfig(
 	)
 	return &Config{impl: impl}
 }
+
+// Validate reports every setting that would make a pool unusable, such as a non-positive
+// MaxConnections or IdleTimeout, a nil Backoff, or MaxRetries == 0 (which allows no dial attempt).
+// Pools are validated when they are created.
+//
+// Returns:
+//   - nil if the configuration is valid, otherwise an error matching ErrInvalidConfig.
+func (c *Config) Validate() error {
+	return c.impl.Validate()
+}
+
+// SetLeakDetection enables reporting of connections that are checked out for longer than threshold.
+// Leaks are reported through the OnConnectionLeak hook (or logged), and connections whose handle is
+// garbage-collected without being released are closed so the pool regains the capacity.
+//
+// Parameters:
+//   - threshold: How long a connection may stay checked out before it is reported (0 disables detection).
+//   - stackSampleRate: The fraction of checkouts, between 0 and 1, that record the acquiring goroutine's stack.
+func (c *Config) SetLeakDetection(threshold time.Duration, stackSampleRate float64) {
+	c.impl.LeakThreshold = threshold
+	c.impl.LeakStackSampleRate = stackSampleRate
+}
+
+// SetDoRetries sets how many times Pool.Do retries a callback that failed with a retryable
+// error (such as a stale connection reset by the peer) on a freshly dialed connection.
+// The pool's backoff strategy is applied between attempts.
+//
+// Parameters:
+//   - retries: The maximum number of retries (0 disables retrying).
+func (c *Config) SetDoRetries(retries uint) {
+	c.impl.DoRetries = retries
+}
+
+// SetDirtyCheck makes Release discard connections that still have unread data pending,
+// such as the tail of a response the caller did not consume, instead of handing them to the
+// next borrower. Discards are reported through OnConnectionDiscard with DiscardDirty.
+// TLS connections are checked on their underlying socket: any TLS record pending counts,
+// but data the TLS layer has already read ahead does not.
+//
+// Parameters:
+//   - enabled: Whether released connections are checked for pending data.
+func (c *Config) SetDirtyCheck(enabled bool) {
+	c.impl.CheckDirtyOnRelease = enabled
+}
+
+// SetRepanicHooks makes the pool re-raise hook panics after reporting them, instead of recovering.
+// This is meant for tests that should fail loudly on a faulty hook.
+//
+// Parameters:
+//   - enabled: Whether hook panics are re-raised.
+func (c *Config) SetRepanicHooks(enabled bool) {
+	c.impl.RepanicHooks = enabled
+}
@@ -0,0 +1,391 @@
+package tcppool
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal/backoff"
+	"github.com/meliadamian17/tcppool/utils"
+)
+
+// Network returns the network the pool dials.
+func (c *Config) Network() string { return c.impl.Network }
+
+// Address returns the network address for the pool's connections.
+func (c *Config) Address() string { return c.impl.Address }
+
+// Endpoints returns the endpoints the pool dials besides its address.
+func (c *Config) Endpoints() []string { return slices.Clone(c.impl.Endpoints) }
+
+// Name returns the pool's name.
+func (c *Config) Name() string { return c.impl.Name }
+
+// MaxConnections returns the maximum number of connections the pool keeps.
+func (c *Config) MaxConnections() int { return c.impl.MaxConnections }
+
+// ConnTimeout returns the timeout for a single dial attempt.
+func (c *Config) ConnTimeout() time.Duration { return c.impl.ConnTimeout }
+
+// IdleTimeout returns the interval at which idle connections are cleaned up.
+func (c *Config) IdleTimeout() time.Duration { return c.impl.IdleTimeout }
+
+// MaxRetries returns the number of dial attempts made before giving up.
+func (c *Config) MaxRetries() uint { return c.impl.MaxRetries }
+
+// Backoff returns the strategy used to space out dial attempts.
+func (c *Config) Backoff() backoff.Backoff { return c.impl.Backoff }
+
+// LeakThreshold returns how long a connection may stay checked out before it is reported.
+func (c *Config) LeakThreshold() time.Duration { return c.impl.LeakThreshold }
+
+// LeakStackSampleRate returns the fraction of checkouts that record the acquiring stack.
+func (c *Config) LeakStackSampleRate() float64 { return c.impl.LeakStackSampleRate }
+
+// DoRetries returns how many times Pool.Do retries a retryable failure.
+func (c *Config) DoRetries() uint { return c.impl.DoRetries }
+
+// DirtyCheck returns whether released connections are checked for unread data.
+func (c *Config) DirtyCheck() bool { return c.impl.CheckDirtyOnRelease }
+
+// RepanicHooks returns whether hook panics are re-raised after being reported.
+func (c *Config) RepanicHooks() bool { return c.impl.RepanicHooks }
+
+// IdleOrder returns the order in which idle connections are reused and retired.
+func (c *Config) IdleOrder() IdleOrder { return c.impl.IdleOrder }
+
+// ValidateConcurrency returns how many idle connections are validated at once, 0 for the default.
+func (c *Config) ValidateConcurrency() int { return c.impl.ValidateConcurrency }
+
+// ReservedConnections returns how many connections only high-priority callers may use.
+func (c *Config) ReservedConnections() int { return c.impl.ReservedConnections }
+
+// TenantQuotas returns the quotas of the tenants that have their own.
+func (c *Config) TenantQuotas() map[string]TenantQuota { return maps.Clone(c.impl.TenantQuotas) }
+
+// DefaultTenantQuota returns the quota of the tenants without their own.
+func (c *Config) DefaultTenantQuota() TenantQuota { return c.impl.DefaultTenantQuota }
+
+// Autoscale returns the autoscaling settings, or nil if the pool has a fixed limit.
+func (c *Config) Autoscale() *AutoscaleConfig {
+	if c.impl.Autoscale == nil {
+		return nil
+	}
+	a := *c.impl.Autoscale
+	return &a
+}
+
+// OutlierDetection returns the outlier detection settings, or nil if it is disabled.
+func (c *Config) OutlierDetection() *OutlierConfig {
+	if c.impl.OutlierDetection == nil {
+		return nil
+	}
+	o := *c.impl.OutlierDetection
+	return &o
+}
+
+// HealthCheck returns the health check settings, or nil if it is disabled.
+func (c *Config) HealthCheck() *HealthCheckConfig {
+	if c.impl.HealthCheck == nil {
+		return nil
+	}
+	h := *c.impl.HealthCheck
+	return &h
+}
+
+// configJSON is the serialized form of a Config. Hooks, dialers and TLS settings are code,
+// not data, and are not part of it.
+type configJSON struct {
+	Name                string                 `json:"name,omitempty"`
+	Network             string                 `json:"network,omitempty"`
+	Address             string                 `json:"address"`
+	Endpoints           []string               `json:"endpoints,omitempty"`
+	MaxConnections      int                    `json:"max_connections"`
+	ConnTimeout         jsonDuration           `json:"conn_timeout"`
+	IdleTimeout         jsonDuration           `json:"idle_timeout"`
+	MaxRetries          uint                   `json:"max_retries"`
+	Backoff             *backoffJSON           `json:"backoff,omitempty"`
+	LeakThreshold       jsonDuration           `json:"leak_threshold,omitempty"`
+	LeakStackSampleRate float64                `json:"leak_stack_sample_rate,omitempty"`
+	DoRetries           uint                   `json:"do_retries,omitempty"`
+	DirtyCheck          bool                   `json:"dirty_check,omitempty"`
+	RepanicHooks        bool                   `json:"repanic_hooks,omitempty"`
+	IdleOrder           IdleOrder              `json:"idle_order,omitempty"`
+	ValidateConcurrency int                    `json:"validate_concurrency,omitempty"`
+	ReservedConnections int                    `json:"reserved_connections,omitempty"`
+	TenantQuotas        map[string]TenantQuota `json:"tenant_quotas,omitempty"`
+	DefaultTenantQuota  *TenantQuota           `json:"default_tenant_quota,omitempty"`
+	Autoscale           *autoscaleJSON         `json:"autoscale,omitempty"`
+	OutlierDetection    *outlierJSON           `json:"outlier_detection,omitempty"`
+	HealthCheck         *healthJSON            `json:"health_check,omitempty"`
+}
+
+// autoscaleJSON is the serialized form of an AutoscaleConfig.
+type autoscaleJSON struct {
+	Min              int            `json:"min"`
+	Max              int            `json:"max"`
+	Algorithm        ScaleAlgorithm `json:"algorithm"`
+	Interval         jsonDuration   `json:"interval,omitempty"`
+	LatencyThreshold jsonDuration   `json:"latency_threshold,omitempty"`
+	Decrease         float64        `json:"decrease,omitempty"`
+	Tolerance        float64        `json:"tolerance,omitempty"`
+}
+
+// MarshalJSON encodes the configuration's data settings. Durations are written as strings
+// such as "2s", and the backoff as its registered name plus parameters, e.g.
+// {"type": "exponential", "base": 1, "max_delay": 10}.
+//
+// Returns:
+//   - The JSON encoding.
+//   - An error, if the backoff strategy does not implement backoff.Describer.
+func (c Config) MarshalJSON() ([]byte, error) {
+	v := c.toJSON()
+	if c.impl.Backoff != nil && v.Backoff == nil {
+		return nil, fmt.Errorf("tcppool: backoff %T cannot be serialized: it does not implement backoff.Describer", c.impl.Backoff)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a configuration produced by MarshalJSON. Settings absent from the
+// document keep their current values, or the NewConfigWith defaults for a zero Config, so a
+// document can override just a few settings. Hooks, dialers, TLS settings and the health check
+// probe are preserved.
+//
+// Parameters:
+//   - data: The JSON document.
+//
+// Returns:
+//   - An error, if the document is malformed or names an unknown backoff.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	if c.impl == nil {
+		*c = *NewConfigWith("")
+		c.impl.Name = ""
+	}
+	v := c.toJSON()
+	// The endpoints and quotas are decoded into fresh values, as decoding into the current ones
+	// would merge the document into them and write through to whatever else shares them.
+	v.Endpoints, v.TenantQuotas = nil, nil
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	impl := *c.impl
+	impl.Name = v.Name
+	impl.Network = v.Network
+	impl.Address = v.Address
+	if v.Endpoints != nil {
+		impl.Endpoints = v.Endpoints
+	}
+	impl.MaxConnections = v.MaxConnections
+	impl.ConnTimeout = time.Duration(v.ConnTimeout)
+	impl.IdleTimeout = time.Duration(v.IdleTimeout)
+	impl.MaxRetries = v.MaxRetries
+	impl.LeakThreshold = time.Duration(v.LeakThreshold)
+	impl.LeakStackSampleRate = v.LeakStackSampleRate
+	impl.DoRetries = v.DoRetries
+	impl.CheckDirtyOnRelease = v.DirtyCheck
+	impl.RepanicHooks = v.RepanicHooks
+	impl.IdleOrder = v.IdleOrder
+	impl.ValidateConcurrency = v.ValidateConcurrency
+	impl.ReservedConnections = v.ReservedConnections
+	if v.TenantQuotas != nil {
+		impl.TenantQuotas = v.TenantQuotas
+	}
+	if v.DefaultTenantQuota != nil {
+		impl.DefaultTenantQuota = *v.DefaultTenantQuota
+	}
+	impl.Autoscale = nil
+	if a := v.Autoscale; a != nil {
+		impl.Autoscale = &AutoscaleConfig{
+			Min:              a.Min,
+			Max:              a.Max,
+			Algorithm:        a.Algorithm,
+			Interval:         time.Duration(a.Interval),
+			LatencyThreshold: time.Duration(a.LatencyThreshold),
+			Decrease:         a.Decrease,
+			Tolerance:        a.Tolerance,
+		}
+	}
+	impl.OutlierDetection = nil
+	if o := v.OutlierDetection; o != nil {
+		impl.OutlierDetection = &OutlierConfig{
+			Interval:           time.Duration(o.Interval),
+			MinRequests:        o.MinRequests,
+			MinHosts:           o.MinHosts,
+			StdevFactor:        o.StdevFactor,
+			FailureThreshold:   o.FailureThreshold,
+			BaseEjectionTime:   time.Duration(o.BaseEjectionTime),
+			MaxEjectionTime:    time.Duration(o.MaxEjectionTime),
+			MaxEjectionPercent: o.MaxEjectionPercent,
+		}
+	}
+	impl.HealthCheck = nil
+	if h := v.HealthCheck; h != nil {
+		impl.HealthCheck = &HealthCheckConfig{
+			Interval: time.Duration(h.Interval),
+			Timeout:  time.Duration(h.Timeout),
+			Rise:     h.Rise,
+			Fall:     h.Fall,
+		}
+		if c.impl.HealthCheck != nil {
+			impl.HealthCheck.Probe = c.impl.HealthCheck.Probe
+		}
+	}
+	if v.Backoff != nil {
+		impl.Backoff = v.Backoff.strategy
+	}
+	if impl.Name == "" {
+		impl.Name = utils.IDByAddress(impl.Address)
+	}
+	*c.impl = impl
+	return nil
+}
+
+// toJSON captures the current settings in their serialized form.
+func (c *Config) toJSON() configJSON {
+	v := configJSON{
+		Name:                c.impl.Name,
+		Network:             c.impl.Network,
+		Address:             c.impl.Address,
+		Endpoints:           c.impl.Endpoints,
+		MaxConnections:      c.impl.MaxConnections,
+		ConnTimeout:         jsonDuration(c.impl.ConnTimeout),
+		IdleTimeout:         jsonDuration(c.impl.IdleTimeout),
+		MaxRetries:          c.impl.MaxRetries,
+		LeakThreshold:       jsonDuration(c.impl.LeakThreshold),
+		LeakStackSampleRate: c.impl.LeakStackSampleRate,
+		DoRetries:           c.impl.DoRetries,
+		DirtyCheck:          c.impl.CheckDirtyOnRelease,
+		RepanicHooks:        c.impl.RepanicHooks,
+		IdleOrder:           c.impl.IdleOrder,
+		ValidateConcurrency: c.impl.ValidateConcurrency,
+		ReservedConnections: c.impl.ReservedConnections,
+		TenantQuotas:        c.impl.TenantQuotas,
+	}
+	if q := c.impl.DefaultTenantQuota; q != (TenantQuota{}) {
+		v.DefaultTenantQuota = &q
+	}
+	if a := c.impl.Autoscale; a != nil {
+		v.Autoscale = &autoscaleJSON{
+			Min:              a.Min,
+			Max:              a.Max,
+			Algorithm:        a.Algorithm,
+			Interval:         jsonDuration(a.Interval),
+			LatencyThreshold: jsonDuration(a.LatencyThreshold),
+			Decrease:         a.Decrease,
+			Tolerance:        a.Tolerance,
+		}
+	}
+	if o := c.impl.OutlierDetection; o != nil {
+		v.OutlierDetection = &outlierJSON{
+			Interval:           jsonDuration(o.Interval),
+			MinRequests:        o.MinRequests,
+			MinHosts:           o.MinHosts,
+			StdevFactor:        o.StdevFactor,
+			FailureThreshold:   o.FailureThreshold,
+			BaseEjectionTime:   jsonDuration(o.BaseEjectionTime),
+			MaxEjectionTime:    jsonDuration(o.MaxEjectionTime),
+			MaxEjectionPercent: o.MaxEjectionPercent,
+		}
+	}
+	if h := c.impl.HealthCheck; h != nil {
+		v.HealthCheck = &healthJSON{
+			Interval: jsonDuration(h.Interval),
+			Timeout:  jsonDuration(h.Timeout),
+			Rise:     h.Rise,
+			Fall:     h.Fall,
+		}
+	}
+	if d, ok := c.impl.Backoff.(backoff.Describer); ok {
+		v.Backoff = &backoffJSON{strategy: c.impl.Backoff}
+		v.Backoff.name, v.Backoff.params = d.Describe()
+	}
+	return v
+}
+
+// jsonDuration is a time.Duration written as a string such as "1m30s".
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durations must be strings such as \"2s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(parsed)
+	return nil
+}
+
+// outlierJSON is the serialized form of an OutlierConfig.
+type outlierJSON struct {
+	Interval           jsonDuration `json:"interval,omitempty"`
+	MinRequests        int          `json:"min_requests,omitempty"`
+	MinHosts           int          `json:"min_hosts,omitempty"`
+	StdevFactor        float64      `json:"stdev_factor,omitempty"`
+	FailureThreshold   float64      `json:"failure_threshold,omitempty"`
+	BaseEjectionTime   jsonDuration `json:"base_ejection_time,omitempty"`
+	MaxEjectionTime    jsonDuration `json:"max_ejection_time,omitempty"`
+	MaxEjectionPercent int          `json:"max_ejection_percent,omitempty"`
+}
+
+// healthJSON is the serialized form of a HealthCheckConfig. The probe is code and is kept
+// when a document is decoded into a Config that already has one.
+type healthJSON struct {
+	Interval jsonDuration `json:"interval,omitempty"`
+	Timeout  jsonDuration `json:"timeout,omitempty"`
+	Rise     int          `json:"rise,omitempty"`
+	Fall     int          `json:"fall,omitempty"`
+}
+
+// backoffJSON is a backoff strategy written as {"type": name, <param>: value, ...}.
+type backoffJSON struct {
+	name     string
+	params   map[string]uint
+	strategy backoff.Backoff
+}
+
+func (b *backoffJSON) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(b.params)+1)
+	for k, v := range b.params {
+		m[k] = v
+	}
+	m["type"] = b.name
+	return json.Marshal(m)
+}
+
+func (b *backoffJSON) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var name string
+	if err := json.Unmarshal(raw["type"], &name); err != nil || name == "" {
+		return fmt.Errorf("backoff needs a \"type\" naming a registered strategy (registered: %v)", backoff.Names())
+	}
+	delete(raw, "type")
+
+	params := make(map[string]uint, len(raw))
+	for k, v := range raw {
+		var n uint
+		if err := json.Unmarshal(v, &n); err != nil {
+			return fmt.Errorf("backoff parameter %q must be a non-negative integer: %w", k, err)
+		}
+		params[k] = n
+	}
+
+	strategy, err := backoff.New(name, params)
+	if err != nil {
+		return err
+	}
+	*b = backoffJSON{name: name, params: params, strategy: strategy}
+	return nil
+}
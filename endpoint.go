@@ -0,0 +1,29 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// OutlierConfig configures the ejection of misbehaving endpoints from a pool created with
+// WithEndpoints. Every Interval, each endpoint's error rate (failed dials, idle connections failing
+// validation and errors passed to ReleaseWithError) and latency (how long its connections are held)
+// are compared with the other endpoints'. Statistical outliers, and endpoints failing at least
+// FailureThreshold of the time, are ejected for BaseEjectionTime, doubled on every further ejection up
+// to MaxEjectionTime, while at most MaxEjectionPercent of the endpoints are out at once. Once its time
+// is over an ejected endpoint is probed with a dial, and returns if the dial succeeds.
+// Statistical outliers are only looked for among at least MinHosts endpoints (5 by default): with
+// fewer, no endpoint can stand StdevFactor standard deviations out, so rely on FailureThreshold.
+// Ejections and returns are reported to the OnEndpointEject and OnEndpointReturn hooks and as events.
+type OutlierConfig = internal.OutlierConfig
+
+// EndpointStats reports the health of one endpoint of a pool. Stats.Endpoints holds one per endpoint.
+type EndpointStats = internal.EndpointStats
+
+// Defaults applied to the unset fields of an OutlierConfig.
+const (
+	DefaultOutlierInterval    = internal.DefaultOutlierInterval
+	DefaultOutlierMinRequests = internal.DefaultOutlierMinRequests
+	DefaultOutlierStdevFactor = internal.DefaultOutlierStdevFactor
+	DefaultOutlierMinHosts    = internal.DefaultOutlierMinHosts
+	DefaultBaseEjectionTime   = internal.DefaultBaseEjectionTime
+	DefaultMaxEjectionTime    = internal.DefaultMaxEjectionTime
+	DefaultMaxEjectionPercent = internal.DefaultMaxEjectionPercent
+)
@@ -0,0 +1,34 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+var (
+	// ErrConnAlreadyReleased is returned when a connection is released more than once.
+	ErrConnAlreadyReleased = internal.ErrConnAlreadyReleased
+	// ErrForeignConn is returned when a connection that was not acquired from the pool is released to it.
+	ErrForeignConn = internal.ErrForeignConn
+	// ErrDirtyConn is matched by the error reported when a connection is released with unread data.
+	ErrDirtyConn = internal.ErrDirtyConn
+	// ErrHookPanic is matched by the error reported when a hook panics.
+	ErrHookPanic = internal.ErrHookPanic
+	// ErrInvalidConfig is matched by every error returned by Config.Validate.
+	ErrInvalidConfig = internal.ErrInvalidConfig
+	// ErrDialFailed is matched by the error returned when every dial attempt for a new connection fails.
+	ErrDialFailed = internal.ErrDialFailed
+	// ErrPoolClosed is returned when a connection is requested from a closed pool.
+	ErrPoolClosed = internal.ErrPoolClosed
+	// ErrQuotaExceeded is matched by the error returned when a tenant already borrows as many
+	// connections as its quota allows.
+	ErrQuotaExceeded = internal.ErrQuotaExceeded
+	// ErrNoHealthyEndpoint is returned when a connection must be dialed while the health check
+	// considers every endpoint of the pool unhealthy.
+	ErrNoHealthyEndpoint = internal.ErrNoHealthyEndpoint
+)
+
+// HookPanicError describes a panic recovered from a hook: the hook's name, the panic value
+// and the stack at the point of the panic.
+type HookPanicError = internal.HookPanicError
+
+// DirtyConnError reports a connection released before its previous response was fully read,
+// along with when (and, if sampled, where) it was checked out.
+type DirtyConnError = internal.DirtyConnError
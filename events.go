@@ -0,0 +1,123 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// Event describes something that happened in a pool: its type, the pool name and address, the
+// connection involved and, depending on the type, a duration, a dial attempt number, a backoff
+// delay, a discard reason or an error. It is also the payload of the OnEvent, OnDialAttempt and
+// OnDialRetry hooks.
+type Event = internal.Event
+
+// EventType identifies a pool lifecycle event.
+type EventType = internal.EventType
+
+const (
+	// EventConnectionCreate is published when a connection is dialed. Duration is the dial time.
+	EventConnectionCreate = internal.EventConnectionCreate
+	// EventConnectionError is published when dialing fails. Err is the final error.
+	EventConnectionError = internal.EventConnectionError
+	// EventConnectionAcquire is published when a connection is checked out. Duration is the wait time.
+	EventConnectionAcquire = internal.EventConnectionAcquire
+	// EventConnectionRelease is published when a connection is returned to the pool. Duration is the hold time.
+	EventConnectionRelease = internal.EventConnectionRelease
+	// EventConnectionClose is published when the pool closes a connection.
+	EventConnectionClose = internal.EventConnectionClose
+	// EventConnectionDiscard is published when a connection is discarded. Reason and Err say why.
+	EventConnectionDiscard = internal.EventConnectionDiscard
+	// EventConnectionLeak is published when a connection is reported as leaked. Duration is the hold time.
+	EventConnectionLeak = internal.EventConnectionLeak
+	// EventDialAttempt is published after every dial attempt. Err is set if the attempt failed.
+	EventDialAttempt = internal.EventDialAttempt
+	// EventDialRetry is published before a failed dial is retried. Delay is the backoff delay.
+	EventDialRetry = internal.EventDialRetry
+	// EventLimitChange is published when the autoscaler changes MaxConnections. Limit is the new
+	// limit and Duration the latency that led to the change.
+	EventLimitChange = internal.EventLimitChange
+	// EventEndpointEject is published when an endpoint is ejected as an outlier. Address is the
+	// endpoint, Duration the ejection time and Err the reason.
+	EventEndpointEject = internal.EventEndpointEject
+	// EventEndpointReturn is published when an ejected endpoint passes its probe and is dialed again.
+	EventEndpointReturn = internal.EventEndpointReturn
+	// EventEndpointHealthy is published when the health check marks an endpoint healthy again.
+	EventEndpointHealthy = internal.EventEndpointHealthy
+	// EventEndpointUnhealthy is published when the health check marks an endpoint unhealthy.
+	// Err is the error of the last probe.
+	EventEndpointUnhealthy = internal.EventEndpointUnhealthy
+)
+
+// EventFilter selects the events a subscriber receives. A nil filter accepts every event.
+type EventFilter = internal.EventFilter
+
+// SubscribeOptions controls how events are queued for a subscriber.
+// By default events that do not fit in a subscriber's queue are dropped (and counted in
+// Stats.EventsDropped) so that a slow subscriber never stalls Get or Release.
+type SubscribeOptions = internal.SubscribeOptions
+
+// EventTypes returns a filter accepting only the given event types.
+//
+// Parameters:
+//   - types: The event types to accept.
+//
+// Returns:
+//   - An EventFilter.
+func EventTypes(types ...EventType) EventFilter {
+	return internal.EventTypes(types...)
+}
+
+// Subscribe returns a channel on which the pool delivers, in order, every event accepted by filter.
+// Events are dropped rather than delivered late when the channel's buffer is full.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//
+// Returns:
+//   - The event channel. It is closed by Unsubscribe.
+func (p *Pool) Subscribe(filter EventFilter) <-chan Event {
+	return p.impl.Subscribe(filter, SubscribeOptions{})
+}
+
+// SubscribeWith is like Subscribe with explicit queueing options.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//   - opts: The queue size and whether publishing blocks on a full queue.
+//
+// Returns:
+//   - The event channel. It is closed by Unsubscribe.
+func (p *Pool) SubscribeWith(filter EventFilter, opts SubscribeOptions) <-chan Event {
+	return p.impl.Subscribe(filter, opts)
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe and closes it.
+//
+// Parameters:
+//   - ch: The channel to unsubscribe.
+func (p *Pool) Unsubscribe(ch <-chan Event) {
+	p.impl.Unsubscribe(ch)
+}
+
+// AddHook registers fn to be called, in order and on its own goroutine, for every event accepted
+// by filter. Any number of hooks can be added, so metrics, logging and tracing can coexist.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//   - fn: The callback.
+//
+// Returns:
+//   - A function that removes the hook.
+func (p *Pool) AddHook(filter EventFilter, fn func(e Event)) func() {
+	return p.impl.AddHook(filter, SubscribeOptions{}, fn)
+}
+
+// AddHookWith is like AddHook with explicit queueing options.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//   - opts: The queue size and whether publishing blocks on a full queue.
+//   - fn: The callback.
+//
+// Returns:
+//   - A function that removes the hook.
+func (p *Pool) AddHookWith(filter EventFilter, opts SubscribeOptions, fn func(e Event)) func() {
+	return p.impl.AddHook(filter, opts, fn)
+}
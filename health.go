@@ -0,0 +1,20 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// HealthCheckConfig configures the active health check of a pool's endpoints: its address and those
+// added with WithEndpoints. Independently of idle validation, each endpoint is dialed every Interval
+// and, if Probe is set, pinged over the new connection. Fall consecutive failures mark the endpoint
+// unhealthy and Rise consecutive successes mark it healthy again. Get never dials an unhealthy
+// endpoint, and fails at once with ErrNoHealthyEndpoint when no endpoint is healthy instead of
+// paying for failed dials and backoff. Changes are reported to the OnHealthChange hook and as
+// events, and Stats.Endpoints shows each endpoint's state.
+type HealthCheckConfig = internal.HealthCheckConfig
+
+// Defaults applied to the unset fields of a HealthCheckConfig.
+const (
+	DefaultHealthInterval = internal.DefaultHealthInterval
+	DefaultHealthTimeout  = internal.DefaultHealthTimeout
+	DefaultHealthRise     = internal.DefaultHealthRise
+	DefaultHealthFall     = internal.DefaultHealthFall
+)
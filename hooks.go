@@ -1,6 +1,7 @@
 package tcppool
 
 import (
+	"context"
 	"net"
 
 	"github.com/meliadamian17/tcppool/internal"
@@ -25,8 +26,52 @@ type PoolHooks struct {
 	OnPoolCreate func(c Config)
 	// OnPoolCreateError is triggered when there is an error during pool creation.
 	OnPoolCreateError func(err error)
+	// OnConnectionLeak is triggered when a connection stays checked out longer than the
+	// leak threshold, or its handle is garbage-collected without being released.
+	OnConnectionLeak func(info LeakInfo)
+	// OnConnectionDiscard is triggered when a connection is discarded instead of being reused.
+	// err is the error that caused the discard, if any.
+	OnConnectionDiscard func(conn net.Conn, reason DiscardReason, err error)
+
+	// OnConnect runs on every newly dialed connection before it is used, e.g. to authenticate,
+	// select a database or negotiate a protocol version. A non-nil error closes the connection
+	// and counts as a failed dial attempt, which is retried with the pool's backoff.
+	OnConnect func(ctx context.Context, conn net.Conn) error
+	// OnBorrow runs every time a connection is about to be handed to a caller.
+	// A non-nil error discards the connection; an idle one is then replaced by another.
+	OnBorrow func(ctx context.Context, conn net.Conn) error
+	// OnReturn runs when a connection is released, e.g. to send a protocol reset.
+	// A non-nil error discards the connection instead of returning it to the pool.
+	OnReturn func(conn net.Conn) error
+
+	// OnHookPanic is triggered when any hook (including event hooks and filters) panics.
+	// The panic is recovered so it cannot crash Get, Release or the pool's background goroutines;
+	// if the hook was handling a connection the pool still owned, that connection is discarded.
+	OnHookPanic func(err *HookPanicError)
+
+	// OnDialAttempt is triggered after every dial attempt, successful or not, with the attempt
+	// number, the address, the time elapsed since dialing started and the error or connection.
+	OnDialAttempt func(info Event)
+	// OnDialRetry is triggered before a failed dial is retried, with the backoff delay in Delay.
+	OnDialRetry func(info Event)
+	// OnEvent is triggered synchronously for every lifecycle event with its full context
+	// (pool, address, connection, duration, attempt, reason and error). It complements the
+	// single-purpose callbacks above, which keep their existing signatures.
+	OnEvent func(info Event)
+
+	// OnEndpointEject is triggered when outlier detection ejects an endpoint, with the endpoint in
+	// Address, the ejection time in Duration and the reason in Err.
+	OnEndpointEject func(info Event)
+	// OnEndpointReturn is triggered when an ejected endpoint passes its probe and is dialed again.
+	OnEndpointReturn func(info Event)
+	// OnHealthChange is triggered when the health check marks an endpoint healthy or unhealthy,
+	// with the endpoint in Address and, for EventEndpointUnhealthy, the last probe error in Err.
+	OnHealthChange func(info Event)
 }
 
+// LeakInfo describes a connection that was checked out and not released in time.
+type LeakInfo = internal.LeakInfo
+
 // ToInternal converts a public PoolHooks object to the corresponding internal representation.
 // This is used to pass hooks from the public API to the internal pool implementation.
 func (h PoolHooks) ToInternal() internal.PoolHooks {
@@ -43,6 +88,18 @@ func (h PoolHooks) ToInternal() internal.PoolHooks {
 				})
 			}
 		},
-		OnPoolCreateError: h.OnPoolCreateError,
+		OnPoolCreateError:   h.OnPoolCreateError,
+		OnConnectionLeak:    h.OnConnectionLeak,
+		OnConnectionDiscard: h.OnConnectionDiscard,
+		OnConnect:           h.OnConnect,
+		OnBorrow:            h.OnBorrow,
+		OnReturn:            h.OnReturn,
+		OnHookPanic:         h.OnHookPanic,
+		OnDialAttempt:       h.OnDialAttempt,
+		OnDialRetry:         h.OnDialRetry,
+		OnEvent:             h.OnEvent,
+		OnEndpointEject:     h.OnEndpointEject,
+		OnEndpointReturn:    h.OnEndpointReturn,
+		OnHealthChange:      h.OnHealthChange,
 	}
 }
@@ -0,0 +1,43 @@
+package tcppool
+
+import (
+	"net"
+
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// ConnInfo is a snapshot of the metadata the pool keeps about a connection: its ID and endpoint,
+// when it was dialed and last used, how many times it was checked out, the bytes read and written
+// through its handles, the last error seen on it and the tags set with PooledConn.SetTag.
+type ConnInfo = internal.ConnInfo
+
+// ConnState says whether a connection is waiting in the pool or checked out.
+type ConnState = internal.ConnState
+
+const (
+	// ConnIdle is the state of a connection waiting in the pool.
+	ConnIdle = internal.ConnIdle
+	// ConnInUse is the state of a checked-out connection.
+	ConnInUse = internal.ConnInUse
+)
+
+// ConnInfo returns the metadata of one of the pool's open connections. Both the handles returned
+// by Get and the raw connections passed to hooks are accepted.
+//
+// Parameters:
+//   - conn: The connection.
+//
+// Returns:
+//   - The connection's metadata.
+//   - false if conn is not an open connection of this pool.
+func (p *Pool) ConnInfo(conn net.Conn) (ConnInfo, bool) {
+	return p.impl.ConnInfo(conn)
+}
+
+// Snapshot lists every open connection of the pool, idle or in use, ordered by ID.
+//
+// Returns:
+//   - The connections' metadata.
+func (p *Pool) Snapshot() []ConnInfo {
+	return p.impl.Snapshot()
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Acquisition is the pending result of an asynchronous Get.
+// It owns the acquired connection until the caller takes it with Result, so a caller
+// that gives up never leaks the connection: it is handed back to the pool instead.
+type Acquisition struct {
+	pool   *ConnectionPool
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	conn      net.Conn
+	err       error
+	taken     bool // Result has handed the connection to the caller
+	abandoned bool // Cancel was called before the connection was taken
+}
+
+// GetAsync starts acquiring a connection in the background and returns immediately.
+// The acquisition stops when ctx is done or Cancel is called.
+//
+// Parameters:
+//   - parent: The context bounding the acquisition.
+//
+// Returns:
+//   - A pointer to the pending Acquisition.
+func (p *ConnectionPool) GetAsync(parent context.Context) *Acquisition {
+	ctx, cancel := context.WithCancel(parent)
+	a := &Acquisition{
+		pool:   p,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer cancel()
+		conn, err := p.GetContext(ctx)
+
+		var unwanted net.Conn
+		a.mu.Lock()
+		if err == nil {
+			switch {
+			case a.abandoned:
+				unwanted, conn, err = conn, nil, context.Canceled
+			case parent.Err() != nil:
+				unwanted, conn, err = conn, nil, parent.Err()
+			}
+		}
+		a.conn, a.err = conn, err
+		close(a.done)
+		a.mu.Unlock()
+
+		// The connection is released outside the lock, as releasing runs the pool's hooks.
+		if unwanted != nil {
+			p.Release(unwanted)
+		}
+	}()
+
+	return a
+}
+
+// Done returns a channel that is closed once the acquisition has completed,
+// successfully or not.
+func (a *Acquisition) Done() <-chan struct{} {
+	return a.done
+}
+
+// Result blocks until the acquisition completes and hands the connection to the caller.
+// From then on the caller is responsible for releasing it.
+//
+// Returns:
+//   - A net.Conn representing the connection.
+//   - An error, if the acquisition failed or was cancelled.
+func (a *Acquisition) Result() (net.Conn, error) {
+	<-a.done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err == nil {
+		a.taken = true
+	}
+	return a.conn, a.err
+}
+
+// Cancel abandons the acquisition. If a connection was (or later is) acquired but not
+// yet taken with Result, it is returned to the pool and Result reports context.Canceled.
+// Cancelling after Result has returned a connection has no effect.
+func (a *Acquisition) Cancel() {
+	var unwanted net.Conn
+	a.mu.Lock()
+	if a.taken {
+		a.mu.Unlock()
+		return
+	}
+	a.abandoned = true
+	a.cancel()
+
+	select {
+	case <-a.done:
+		if a.err == nil {
+			unwanted = a.conn
+			a.conn, a.err = nil, context.Canceled
+		}
+	default:
+	}
+	a.mu.Unlock()
+
+	if unwanted != nil {
+		a.pool.Release(unwanted)
+	}
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// GetForKey retrieves a connection like GetContext, preferring an idle connection last used
+// with key, so that server-side session state (prepared statements, subscriptions, auth context)
+// can be reused. Without such a connection, any idle connection is taken or a new one is dialed.
+// The returned connection is recorded as last used with key.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - key: The session key; an empty key behaves like GetContext.
+//
+// Returns:
+//   - A net.Conn object representing the connection.
+//   - An error, if the connection retrieval fails or ctx is done first.
+func (p *ConnectionPool) GetForKey(ctx context.Context, key string) (net.Conn, error) {
+	start := time.Now()
+	req := newRequest(ctx, false, key)
+	conn, err := p.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if p.info(conn).swapKey(key) == key {
+			p.counters.keyHits.Add(1)
+		} else {
+			p.counters.keyMisses.Add(1)
+		}
+	}
+	return p.checkout(conn, start, req), nil
+}
+
+// popIdle takes the next idle connection, preferring one last used with key.
+//
+// Parameters:
+//   - key: The session key, or empty for no preference.
+//
+// Returns:
+//   - The idle connection.
+//   - false if there is no idle connection.
+func (p *ConnectionPool) popIdle(key string) (IdleConn, bool) {
+	if key != "" {
+		taken := false
+		match := p.idle.RemoveIf(func(c IdleConn) bool {
+			if taken || c.Key != key {
+				return false
+			}
+			taken = true
+			return true
+		})
+		if len(match) > 0 {
+			return match[0], true
+		}
+	}
+	return p.idle.Pop()
+}
+
+// swapKey records the session key a connection is used with.
+//
+// Parameters:
+//   - key: The new session key.
+//
+// Returns:
+//   - The previous session key.
+func (i *connInfo) swapKey(key string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	old := i.key
+	i.key = key
+	return old
+}
+
+// sessionKey returns the session key the connection was last used with.
+func (i *connInfo) sessionKey() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.key
+}
+
+// keyedConns maps every session key to the IDs of the open connections last used with it.
+//
+// Returns:
+//   - The mapping, or nil if no connection has a session key.
+func (p *ConnectionPool) keyedConns() map[string][]uint64 {
+	var keys map[string][]uint64
+	for _, info := range p.Snapshot() {
+		if info.Key == "" {
+			continue
+		}
+		if keys == nil {
+			keys = make(map[string][]uint64)
+		}
+		keys[info.Key] = append(keys[info.Key], info.ID)
+	}
+	return keys
+}
@@ -0,0 +1,324 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScaleAlgorithm selects how an autoscaled pool adjusts its connection limit.
+type ScaleAlgorithm string
+
+const (
+	// ScaleAIMD grows the limit by one connection per interval while the pool is saturated and cuts
+	// it by the Decrease factor when the latency exceeds LatencyThreshold.
+	ScaleAIMD ScaleAlgorithm = "aimd"
+	// ScaleGradient compares the latency with a slowly moving baseline, like Vegas or Netflix's
+	// gradient limiter: the limit shrinks in proportion to how much the backend slowed down, and
+	// grows by about the square root of the limit while the pool is saturated and latency stays
+	// near the baseline.
+	ScaleGradient ScaleAlgorithm = "gradient"
+)
+
+// Defaults applied to the unset fields of an AutoscaleConfig.
+const (
+	DefaultScaleInterval  = time.Second
+	DefaultScaleDecrease  = 0.9
+	DefaultScaleTolerance = 2.0
+)
+
+// minScaleQueued is the average number of queued callers over an interval below which the
+// queuing is too brief to call the pool saturated, e.g. a caller woken by the last raise.
+const minScaleQueued = 0.1
+
+// AutoscaleConfig makes a pool adjust MaxConnections between Min and Max from the latency of the
+// backend, measured as the time connections are held, and from how saturated the pool is: the time
+// callers spent queuing for a connection and the share of the limit checked out. The pool is
+// saturated when callers queued while the whole limit was checked out; raising the limit cannot
+// help callers held back by something else, such as a tenant quota. An interval in which no
+// connection is released gives no latency sample, so only saturation counts then.
+type AutoscaleConfig struct {
+	Min       int            // Lowest limit, greater than ReservedConnections
+	Max       int            // Highest limit
+	Algorithm ScaleAlgorithm // ScaleAIMD or ScaleGradient
+	Interval  time.Duration  // How often the limit is adjusted (DefaultScaleInterval if 0)
+
+	LatencyThreshold time.Duration // ScaleAIMD: average latency above which the limit is cut
+	Decrease         float64       // ScaleAIMD: factor applied to the limit when it is cut (DefaultScaleDecrease if 0)
+	Tolerance        float64       // ScaleGradient: latency/baseline ratio tolerated before shrinking (DefaultScaleTolerance if 0)
+}
+
+// validate checks the autoscaling settings against a pool starting at maxConnections
+// with reserved connections.
+func (a *AutoscaleConfig) validate(maxConnections, reserved int) []error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: autoscale: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+	if a.Min <= reserved || a.Max < a.Min {
+		invalid("limits must satisfy reserved connections < min <= max, got min %d, max %d", a.Min, a.Max)
+	}
+	if maxConnections < a.Min || maxConnections > a.Max {
+		invalid("max connections must start between min and max, got %d", maxConnections)
+	}
+	if a.Interval < 0 {
+		invalid("interval must not be negative, got %v", a.Interval)
+	}
+	switch a.Algorithm {
+	case ScaleAIMD:
+		if a.LatencyThreshold <= 0 {
+			invalid("the aimd algorithm needs a positive latency threshold")
+		}
+		if a.Decrease < 0 || a.Decrease >= 1 {
+			invalid("decrease must be between 0 and 1, got %v", a.Decrease)
+		}
+	case ScaleGradient:
+		if a.Tolerance != 0 && a.Tolerance < 1 {
+			invalid("tolerance must be at least 1, got %v", a.Tolerance)
+		}
+	default:
+		invalid("algorithm must be %q or %q, got %q", ScaleAIMD, ScaleGradient, a.Algorithm)
+	}
+	return errs
+}
+
+// AutoscaleStats reports the state of an autoscaled pool.
+type AutoscaleStats struct {
+	Limit       int           // The connection limit in effect
+	Latency     time.Duration // Average latency over the last interval, or 0 if no connection was released
+	Queued      float64       // Average number of callers waiting for a connection over the last interval
+	Utilization float64       // Highest share of the limit checked out during the last interval
+	Baseline    time.Duration // ScaleGradient: the latency the backend shows when it is not overloaded
+	Increases   uint64        // Times the limit was raised
+	Decreases   uint64        // Times the limit was lowered
+}
+
+// autoscaler holds the measurements and state behind a pool's autoscaling.
+type autoscaler struct {
+	cfg AutoscaleConfig
+
+	holdNanos atomic.Int64 // Time connections were held during the interval
+	holds     atomic.Int64 // Connections released or discarded during the interval
+	peakInUse atomic.Int64 // Most connections checked out at once during the interval
+
+	// The time callers spent queued during the interval, with the wait of callers still queued
+	// counted up to now: queuedNanos plus queuing*now minus queuedFrom. Times are nanoseconds
+	// since epoch.
+	qmu         sync.Mutex
+	epoch       time.Time
+	started     int64 // Start of the interval
+	queuing     int64 // Callers queued right now
+	queuedFrom  int64 // Sum of when the queued callers started counting in this interval
+	queuedNanos int64 // Time counted for callers that left the queue during the interval
+
+	mu          sync.Mutex
+	limit       float64 // The limit, before rounding
+	latency     time.Duration
+	queued      float64
+	utilization float64
+	baseline    float64 // Nanoseconds
+	increases   uint64
+	decreases   uint64
+}
+
+// newAutoscaler prepares autoscaling with cfg, filling in the defaults.
+func newAutoscaler(cfg AutoscaleConfig) *autoscaler {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultScaleInterval
+	}
+	if cfg.Decrease == 0 {
+		cfg.Decrease = DefaultScaleDecrease
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = DefaultScaleTolerance
+	}
+	return &autoscaler{cfg: cfg, epoch: time.Now()}
+}
+
+// observe records how long a connection was held.
+func (a *autoscaler) observe(held time.Duration) {
+	a.holdNanos.Add(int64(held))
+	a.holds.Add(1)
+}
+
+// enqueue records a caller starting to wait for a connection at start.
+func (a *autoscaler) enqueue(start time.Time) {
+	a.qmu.Lock()
+	a.queuing++
+	a.queuedFrom += max(int64(start.Sub(a.epoch)), a.started)
+	a.qmu.Unlock()
+}
+
+// dequeue records a caller that started to wait at start leaving the queue now.
+func (a *autoscaler) dequeue(start time.Time) {
+	a.qmu.Lock()
+	from := max(int64(start.Sub(a.epoch)), a.started)
+	a.queuing--
+	a.queuedFrom -= from
+	a.queuedNanos += int64(time.Since(a.epoch)) - from
+	a.qmu.Unlock()
+}
+
+// queueLength returns the average number of callers queued since the interval started, and
+// starts the next interval.
+func (a *autoscaler) queueLength() float64 {
+	a.qmu.Lock()
+	defer a.qmu.Unlock()
+	now := int64(time.Since(a.epoch))
+	total := a.queuedNanos + a.queuing*now - a.queuedFrom
+	elapsed := now - a.started
+	a.started, a.queuedNanos, a.queuedFrom = now, 0, a.queuing*now
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(total) / float64(elapsed)
+}
+
+// checkedOut records the number of connections checked out after a checkout.
+func (a *autoscaler) checkedOut(inUse int64) {
+	for {
+		peak := a.peakInUse.Load()
+		if inUse <= peak || a.peakInUse.CompareAndSwap(peak, inUse) {
+			return
+		}
+	}
+}
+
+// next computes the limit for the coming interval from the one in effect and resets the
+// interval's measurements.
+//
+// Parameters:
+//   - current: The limit in effect; it replaces the autoscaler's own if they differ, e.g. after Resize.
+//   - inUse: The connections checked out right now.
+//
+// Returns:
+//   - The new limit, between Min and Max.
+func (a *autoscaler) next(current, inUse int) int {
+	holds := a.holds.Swap(0)
+	nanos := a.holdNanos.Swap(0)
+	// By Little's law, the time callers spent queued over the interval is the average queue length.
+	queued := a.queueLength()
+	utilization := float64(max(a.peakInUse.Swap(0), int64(inUse))) / float64(current)
+	saturated := queued >= minScaleQueued && utilization >= 1
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if int(math.Round(a.limit)) != current {
+		a.limit = float64(current)
+	}
+	a.queued, a.utilization = queued, utilization
+	a.latency = 0
+	if holds == 0 && !saturated {
+		return current
+	}
+
+	// Without a release there is no latency sample: connections are held longer than the
+	// interval, by the backend or by the callers, and only saturation is known.
+	limit := a.limit
+	latency := 0.0
+	if holds > 0 {
+		latency = float64(nanos) / float64(holds)
+		a.latency = time.Duration(latency)
+	}
+	switch a.cfg.Algorithm {
+	case ScaleAIMD:
+		switch {
+		case a.latency > a.cfg.LatencyThreshold:
+			limit *= a.cfg.Decrease
+		case saturated:
+			limit++
+		}
+	case ScaleGradient:
+		gradient := 1.0
+		if holds > 0 {
+			if a.baseline == 0 || latency < a.baseline {
+				a.baseline = latency
+			} else {
+				// The baseline drifts slowly towards the latency, so a lasting change of the
+				// backend's speed is eventually accepted as normal.
+				a.baseline += (latency - a.baseline) * 0.05
+			}
+			gradient = max(0.5, min(1, a.cfg.Tolerance*a.baseline/latency))
+		}
+		target := limit * gradient
+		if saturated && gradient == 1 {
+			target += math.Sqrt(limit)
+		}
+		limit = limit*0.8 + target*0.2
+	}
+	limit = max(float64(a.cfg.Min), min(float64(a.cfg.Max), limit))
+
+	next := int(math.Round(limit))
+	switch {
+	case next > current:
+		a.increases++
+	case next < current:
+		a.decreases++
+	}
+	a.limit = limit
+	return next
+}
+
+// stats returns the autoscaler's state.
+func (a *autoscaler) stats(limit int) *AutoscaleStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &AutoscaleStats{
+		Limit:       limit,
+		Latency:     a.latency,
+		Queued:      a.queued,
+		Utilization: a.utilization,
+		Baseline:    time.Duration(a.baseline),
+		Increases:   a.increases,
+		Decreases:   a.decreases,
+	}
+}
+
+// startQueuing reports a caller starting to wait for a connection to the autoscaler, if the pool
+// has one.
+//
+// Returns:
+//   - When the caller started to wait, to pass to stopQueuing.
+func (p *ConnectionPool) startQueuing() time.Time {
+	start := time.Now()
+	if p.scaler != nil {
+		p.scaler.enqueue(start)
+	}
+	return start
+}
+
+// stopQueuing reports a caller that started to wait at start leaving the queue.
+func (p *ConnectionPool) stopQueuing(start time.Time) {
+	if p.scaler != nil {
+		p.scaler.dequeue(start)
+	}
+}
+
+// Autoscale adjusts MaxConnections every AutoscaleConfig.Interval until the pool is closed.
+func (p *ConnectionPool) Autoscale() {
+	ticker := time.NewTicker(p.scaler.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+
+		current := p.settings().maxConnections
+		next := p.scaler.next(current, p.InUse())
+		if next == current {
+			continue
+		}
+		if err := p.Resize(next); err != nil {
+			fmt.Printf("Failed to adjust connection limit of pool %s: %v\n", p.Name, err)
+			continue
+		}
+		p.publish(Event{Type: EventLimitChange, Limit: next, Duration: p.scaler.stats(next).Latency})
+		if p.Hooks.OnEvent == nil {
+			fmt.Printf("Adjusted connection limit of pool %s from %d to %d\n", p.Name, current, next)
+		}
+	}
+}
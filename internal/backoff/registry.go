@@ -0,0 +1,87 @@
+package backoff
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory builds a backoff strategy from named parameters.
+type Factory func(params map[string]uint) (Backoff, error)
+
+// Describer is implemented by strategies that can report the name and parameters they were
+// built from, so that a configuration using them can be serialized.
+type Describer interface {
+	Describe() (name string, params map[string]uint)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"exponential": func(p map[string]uint) (Backoff, error) {
+			return &ExponentialBackoff{Base: p["base"], MaxDelay: p["max_delay"]}, nil
+		},
+		"fibonacci": func(p map[string]uint) (Backoff, error) {
+			return &FibonacciBackoff{MaxDelay: p["max_delay"]}, nil
+		},
+		"fixed": func(p map[string]uint) (Backoff, error) {
+			return &FixedBackoff{Interval: p["interval"]}, nil
+		},
+		"linear": func(p map[string]uint) (Backoff, error) {
+			return &LinearBackoff{Scalar: p["scalar"]}, nil
+		},
+		"polynomial": func(p map[string]uint) (Backoff, error) {
+			return &PolynomialBackoff{Exponent: p["exponent"]}, nil
+		},
+	}
+)
+
+// Register makes a strategy available by name, replacing any previous registration.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = f
+}
+
+// New builds the strategy registered under name.
+func New(name string, params map[string]uint) (Backoff, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown backoff %q (registered: %v)", name, Names())
+	}
+	return f(params)
+}
+
+// Names lists the registered strategy names in alphabetical order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *ExponentialBackoff) Describe() (string, map[string]uint) {
+	return "exponential", map[string]uint{"base": b.Base, "max_delay": b.MaxDelay}
+}
+
+func (b *FibonacciBackoff) Describe() (string, map[string]uint) {
+	return "fibonacci", map[string]uint{"max_delay": b.MaxDelay}
+}
+
+func (b *FixedBackoff) Describe() (string, map[string]uint) {
+	return "fixed", map[string]uint{"interval": b.Interval}
+}
+
+func (b *LinearBackoff) Describe() (string, map[string]uint) {
+	return "linear", map[string]uint{"scalar": b.Scalar}
+}
+
+func (b *PolynomialBackoff) Describe() (string, map[string]uint) {
+	return "polynomial", map[string]uint{"exponent": b.Exponent}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// IsBrokenConn reports whether err, returned while using a connection, means the stream
+// can no longer be trusted and the connection must not be reused.
+//
+// Parameters:
+//   - err: The error observed on the connection.
+//
+// Returns:
+//   - A boolean indicating whether the connection is broken.
+func IsBrokenConn(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var errno syscall.Errno
+	return errors.As(err, &errno)
+}
+
+// IsRetryable reports whether err is the kind of failure a stale pooled connection produces
+// on first use (the peer closed or reset it while it sat idle), so that repeating the
+// operation on a freshly dialed connection is expected to succeed.
+//
+// Parameters:
+//   - err: The error observed on the connection.
+//
+// Returns:
+//   - A boolean indicating whether the operation may be retried.
+func IsRetryable(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, net.ErrClosed)
+}
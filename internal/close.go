@@ -0,0 +1,52 @@
+package internal
+
+import "errors"
+
+// ErrPoolClosed is returned when a connection is requested from a closed pool.
+var ErrPoolClosed = errors.New("tcppool: pool is closed")
+
+// Close shuts the pool down. Idle connections are closed right away and checked-out ones when
+// they are released. Callers waiting for a connection, and later calls to Get, fail with ErrPoolClosed.
+// The background cleanup and leak detection goroutines stop. Closing a closed pool does nothing.
+//
+// Returns:
+//   - nil; the error is reserved for future use.
+func (p *ConnectionPool) Close() error {
+	p.mu.Lock()
+	if p.closed.Load() {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed.Store(true)
+	close(p.done)
+	p.notifyLocked()
+	p.mu.Unlock()
+
+	if p.limiter != nil {
+		p.limiter.unregister(p)
+	}
+	p.drainIdle("Closing idle connection of a closed pool")
+	return nil
+}
+
+// drainIdle closes every idle connection.
+//
+// Parameters:
+//   - why: The message logged for each connection when no OnConnectionClose hook is set.
+func (p *ConnectionPool) drainIdle(why string) {
+	for {
+		idle, ok := p.idle.Pop()
+		if !ok {
+			return
+		}
+		p.closeConn(idle.Conn, why)
+	}
+}
+
+// Closed reports whether Close has been called.
+//
+// Returns:
+//   - true if the pool is closed.
+func (p *ConnectionPool) Closed() bool {
+	return p.closed.Load()
+}
@@ -1,15 +1,28 @@
 package internal
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"time"
 
 	"github.com/meliadamian17/tcppool/internal/backoff"
 	"github.com/meliadamian17/tcppool/utils"
 )
 
+// ErrInvalidConfig is matched by every error returned by ConfigImpl.Validate.
+var ErrInvalidConfig = errors.New("tcppool: invalid config")
+
+// DialFunc dials a connection, like net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
 // ConfigImpl holds the internal configuration for the connection pool.
 type ConfigImpl struct {
+	Network        string
 	Address        string
+	Endpoints      []string
 	Name           string
 	MaxConnections int
 	ConnTimeout    time.Duration
@@ -17,6 +30,24 @@ type ConfigImpl struct {
 	MaxRetries     uint
 	Backoff        backoff.Backoff
 	Hooks          PoolHooks
+
+	LeakThreshold       time.Duration
+	LeakStackSampleRate float64
+	DoRetries           uint
+	CheckDirtyOnRelease bool
+	RepanicHooks        bool
+	IdleOrder           IdleOrder
+	ValidateConcurrency int
+	ReservedConnections int
+	TenantQuotas        map[string]TenantQuota
+	DefaultTenantQuota  TenantQuota
+	Autoscale           *AutoscaleConfig
+	OutlierDetection    *OutlierConfig
+	HealthCheck         *HealthCheckConfig
+
+	Dialer    DialFunc
+	TLSConfig *tls.Config
+	Limiter   *Limiter
 }
 
 // NewConfig creates a new ConfigImpl instance.
@@ -55,3 +86,84 @@ func NewConfig(
 		Hooks:          hooks,
 	}
 }
+
+// Validate reports every setting that would make a pool unusable.
+//
+// Returns:
+//   - nil if the configuration is valid, otherwise an error matching ErrInvalidConfig
+//     that lists each problem.
+func (c *ConfigImpl) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+
+	if c.Address == "" {
+		invalid("address must not be empty")
+	}
+	seen := map[string]bool{c.Address: true}
+	for _, addr := range c.Endpoints {
+		if addr == "" || seen[addr] {
+			invalid("endpoints must be set and differ from each other and from the address, got %q", addr)
+		}
+		seen[addr] = true
+	}
+	if c.MaxConnections <= 0 {
+		invalid("max connections must be greater than 0, got %d", c.MaxConnections)
+	}
+	if c.ConnTimeout < 0 {
+		invalid("connection timeout must not be negative, got %v", c.ConnTimeout)
+	}
+	if c.IdleTimeout <= 0 {
+		invalid("idle timeout must be greater than 0, got %v", c.IdleTimeout)
+	}
+	if c.MaxRetries == 0 {
+		invalid("max retries must be at least 1, since it is the number of dial attempts")
+	}
+	if c.Backoff == nil {
+		invalid("backoff must not be nil")
+	}
+	if c.LeakThreshold < 0 {
+		invalid("leak threshold must not be negative, got %v", c.LeakThreshold)
+	}
+	if c.LeakStackSampleRate < 0 || c.LeakStackSampleRate > 1 {
+		invalid("leak stack sample rate must be between 0 and 1, got %v", c.LeakStackSampleRate)
+	}
+	if c.ValidateConcurrency < 0 {
+		invalid("validate concurrency must not be negative, got %d", c.ValidateConcurrency)
+	}
+	if c.ReservedConnections < 0 || (c.MaxConnections > 0 && c.ReservedConnections >= c.MaxConnections) {
+		invalid("reserved connections must be between 0 and max connections - 1, got %d", c.ReservedConnections)
+	}
+	for tenant, quota := range c.TenantQuotas {
+		if err := quota.validate(tenant, c.MaxConnections); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.MaxConnections > 0 {
+		// An autoscaled pool may shrink to its minimum; the guaranteed minimums must fit even then.
+		smallest := c.MaxConnections
+		if c.Autoscale != nil && c.Autoscale.Min > 0 && c.Autoscale.Min < smallest {
+			smallest = c.Autoscale.Min
+		}
+		if err := checkMinimums(c.TenantQuotas, smallest-max(c.ReservedConnections, 0)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.DefaultTenantQuota.Max < 0 || c.DefaultTenantQuota.Min != 0 {
+		invalid("default tenant quota must have a non-negative max and no guaranteed minimum")
+	}
+	if c.Autoscale != nil {
+		errs = append(errs, c.Autoscale.validate(c.MaxConnections, c.ReservedConnections)...)
+	}
+	if c.OutlierDetection != nil {
+		errs = append(errs, c.OutlierDetection.validate(1+len(c.Endpoints))...)
+	}
+	if c.HealthCheck != nil {
+		errs = append(errs, c.HealthCheck.validate()...)
+	}
+	if !c.IdleOrder.valid() {
+		invalid("idle order must be %q, %q or %q, got %q", IdleLIFO, IdleFIFO, IdleRetireOldest, c.IdleOrder)
+	}
+	return errors.Join(errs...)
+}
@@ -3,57 +3,145 @@ package internal
 import (
 	"fmt"
 	"net"
+	"sync"
 	"time"
 )
 
-// Validate checks if a given connection is still valid by performing a read operation with a timeout.
-// It determines whether the connection is idle, invalid, or active.
+// alive reports, without blocking, whether an idle connection is still open: the peer has not
+// closed it and the socket has not failed. It does not wait for data, so quiet connections pass,
+// and it consumes nothing.
 //
 // Parameters:
-//   - c: The connection to validate.
+//   - c: The connection to check.
 //
 // Returns:
-//   - A boolean indicating whether the connection is valid.
-func Validate(c net.Conn) bool {
-	c.SetReadDeadline(time.Now().Add(1 * time.Second))
-	one := make([]byte, 1)
-	_, err := c.Read(one)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			fmt.Println("Connection is idle (timeout occurred)")
-			return false
-		}
+//   - A boolean indicating whether the connection is alive.
+func alive(c net.Conn) bool {
+	if _, err := peekPending(c, 1); err != nil {
 		fmt.Printf("Connection is invalid: %v\n", err)
 		return false
 	}
-	fmt.Println("Connection is valid (data received)")
 	return true
 }
 
-// CleanupIdleConns periodically checks for idle connections and removes them if they are no longer valid.
+// DefaultValidateConcurrency is the number of idle connections validated at once when
+// ValidateConcurrency is not set.
+const DefaultValidateConcurrency = 4
+
+// CleanupIdleConns closes every idle connection as soon as it has been idle for IdleTimeout,
+// and validates the remaining idle connections once per IdleTimeout, in the background.
+// Each idle connection's deadline is the time it was released plus IdleTimeout. Since connections
+// are released in time order, the earliest deadline is always that of the least recently released
+// connection, which the idle store reports, so a single timer armed at that deadline expires
+// exactly the connections that are due and nothing else. Connections taken out for validation
+// are invisible to the store meanwhile, so the timer is re-armed when they are put back.
+// It returns once the pool is closed.
 func (p *ConnectionPool) CleanupIdleConns() {
-	ticker := time.NewTicker(p.IdleTimeout)
-	defer ticker.Stop()
-	for range ticker.C {
-		numIdle := len(p.IdleConns)
-		fmt.Printf("Idle connections to process: %d\n", numIdle)
-
-		for i := 0; i < numIdle; i++ {
-			select {
-			case c := <-p.IdleConns:
-				if !Validate(c) {
-					fmt.Println("Cleaning up idle connection")
-					c.Close()
-					if p.Hooks.OnConnectionClose != nil {
-						p.Hooks.OnConnectionClose(c)
-					}
-				} else {
-					fmt.Println("Connection is valid, requeuing")
-					p.IdleConns <- c
+	timer := time.NewTimer(p.settings().idleTimeout)
+	defer timer.Stop()
+	nextCheck := time.Now().Add(p.settings().idleTimeout)
+	for {
+		select {
+		case <-timer.C:
+		case <-p.requeued:
+		case <-p.done:
+			return
+		}
+
+		now := time.Now()
+		timeout := p.settings().idleTimeout
+		p.expireIdle(now, timeout)
+		if !now.Before(nextCheck) {
+			// Validation can take a while; run it aside so that expiry stays on time.
+			if p.validating.CompareAndSwap(false, true) {
+				go func() {
+					defer p.validating.Store(false)
+					p.validateIdle()
+				}()
+			}
+			nextCheck = now.Add(timeout)
+		}
+
+		wake := nextCheck
+		if since, ok := p.idle.OldestSince(); ok && since.Add(timeout).Before(wake) {
+			wake = since.Add(timeout)
+		}
+		timer.Reset(time.Until(wake))
+	}
+}
+
+// expireIdle closes the idle connections whose idle deadline has passed, coldest first.
+//
+// Parameters:
+//   - now: The current time.
+//   - timeout: The IdleTimeout in effect.
+func (p *ConnectionPool) expireIdle(now time.Time, timeout time.Duration) {
+	expired := p.idle.RemoveIf(func(c IdleConn) bool {
+		return !now.Before(c.Since.Add(timeout))
+	})
+	for _, c := range expired {
+		p.closeConn(c.Conn, "Closing connection idle for longer than the idle timeout")
+	}
+}
+
+// validateIdle checks every idle connection with alive, closing the dead ones. At most
+// ValidateConcurrency connections are taken out of the pool and checked at a time, coldest first.
+// The checks do not block, so each batch is back in the pool at once and Get rarely misses it;
+// quiet connections are left for expiry at their own idle deadline.
+func (p *ConnectionPool) validateIdle() {
+	limit := p.ValidateConcurrency
+	if limit <= 0 {
+		limit = DefaultValidateConcurrency
+	}
+
+	checked := make(map[net.Conn]bool)
+	for {
+		taken := 0
+		batch := p.idle.RemoveIf(func(c IdleConn) bool {
+			if taken >= limit || checked[c.Conn] {
+				return false
+			}
+			taken++
+			return true
+		})
+		if len(batch) == 0 {
+			return
+		}
+
+		valid := make([]bool, len(batch))
+		var wg sync.WaitGroup
+		for i, c := range batch {
+			checked[c.Conn] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				valid[i] = alive(c.Conn)
+			}()
+		}
+		wg.Wait()
+
+		for i, c := range batch {
+			switch {
+			case !valid[i]:
+				if e := p.endpointOf(c.Conn); e != nil {
+					e.record(true)
 				}
+				p.closeConn(c.Conn, "Cleaning up idle connection")
+			case !p.idle.Push(c):
+				p.closeConn(c.Conn, "Cleaning up idle connection")
 			default:
-				fmt.Println("No more idle connections to process")
+				fmt.Println("Connection is valid, requeuing")
+				p.wakeWaiters()
 			}
 		}
+		if p.closed.Load() {
+			// Close may have drained the store before the batch was pushed back.
+			p.drainIdle("Closing idle connection of a closed pool")
+			return
+		}
+		select {
+		case p.requeued <- struct{}{}:
+		default:
+		}
 	}
 }
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDirtyConn is matched by errors reporting a connection released with unread data pending.
+var ErrDirtyConn = errors.New("tcppool: connection released with unread data")
+
+// DirtyConnError reports a connection released before its previous response was fully read.
+// It carries the checkout details so the caller that forgot to drain the response can be found.
+type DirtyConnError struct {
+	Pending    int       // Bytes readable at release time (a lower bound)
+	AcquiredAt time.Time // When the connection was checked out
+	Stack      string    // Stack of the acquiring goroutine, if it was sampled
+}
+
+func (e *DirtyConnError) Error() string {
+	return fmt.Sprintf("%v: %d bytes pending, checked out at %v", ErrDirtyConn, e.Pending, e.AcquiredAt.Format(time.RFC3339Nano))
+}
+
+func (e *DirtyConnError) Unwrap() error {
+	return ErrDirtyConn
+}
+
+// dirtyPeekLimit is the most pending bytes checkDirty counts.
+const dirtyPeekLimit = 4096
+
+// buffered is implemented by connection wrappers that hold read-ahead data.
+type buffered interface {
+	Buffered() int
+}
+
+// checkDirty inspects a connection being released for unread data without consuming it.
+//
+// Parameters:
+//   - l: The lease ending with the release.
+//
+// Returns:
+//   - A *DirtyConnError if data is pending, io.EOF if the peer closed the connection, or nil.
+func (p *ConnectionPool) checkDirty(l *lease) error {
+	n := 0
+	if b, ok := l.conn.(buffered); ok {
+		n = b.Buffered()
+	}
+	if n == 0 {
+		pending, err := peekPending(l.conn, dirtyPeekLimit)
+		if err != nil {
+			return err
+		}
+		n = pending
+	}
+	if n == 0 {
+		return nil
+	}
+	return &DirtyConnError{Pending: n, AcquiredAt: l.acquiredAt, Stack: l.stack}
+}
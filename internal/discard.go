@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+)
+
+// ReleaseWithError returns a connection to the pool after the caller observed err on it.
+// If err means the stream is broken, the connection is discarded instead of being handed
+// to the next caller; otherwise it is released normally.
+//
+// Parameters:
+//   - conn: The connection to be returned to the pool, as returned by Get.
+//   - err: The error the caller observed while using the connection, or nil.
+//
+// Returns:
+//   - An error, if the release process fails or conn was not acquired from this pool.
+func (p *ConnectionPool) ReleaseWithError(conn net.Conn, err error) error {
+	if IsBrokenConn(err) {
+		return p.discard(conn, DiscardError, err)
+	}
+	if err != nil {
+		if pc, ok := conn.(*PooledConn); ok && pc.pool == p {
+			pc.info.setErr(err)
+		}
+	}
+	return p.release(conn, err)
+}
+
+// Discard closes a checked-out connection instead of returning it to the pool.
+//
+// Parameters:
+//   - conn: The connection to dispose of, as returned by Get.
+//
+// Returns:
+//   - An error, if conn was not acquired from this pool or closing fails.
+func (p *ConnectionPool) Discard(conn net.Conn) error {
+	return p.discard(conn, DiscardExplicit, nil)
+}
+
+// discard takes a checked-out connection out of circulation, reports why and closes it.
+//
+// Parameters:
+//   - conn: The connection handle to dispose of.
+//   - reason: Why the connection is discarded.
+//   - cause: The error that led to the discard, if any.
+//
+// Returns:
+//   - An error, if conn is not a live handle from this pool or closing fails.
+func (p *ConnectionPool) discard(conn net.Conn, reason DiscardReason, cause error) error {
+	l, err := p.checkin(conn)
+	if err != nil {
+		return err
+	}
+	if reason == DiscardError {
+		p.settle(l, cause)
+	}
+	return p.discardRaw(l.conn, reason, cause)
+}
+
+// discardRaw reports why a raw connection is being discarded and closes it.
+//
+// Parameters:
+//   - raw: The raw connection to dispose of.
+//   - reason: Why the connection is discarded.
+//   - cause: The error that led to the discard, if any.
+//
+// Returns:
+//   - The error returned by Close.
+func (p *ConnectionPool) discardRaw(raw net.Conn, reason DiscardReason, cause error) error {
+	p.mu.Lock()
+	p.discarded[reason]++
+	p.mu.Unlock()
+	if cause != nil {
+		p.info(raw).setErr(cause)
+	}
+	p.publish(Event{Type: EventConnectionDiscard, Conn: raw, Reason: reason, Err: cause})
+
+	if p.Hooks.OnConnectionDiscard != nil {
+		p.callHook("OnConnectionDiscard", func() { p.Hooks.OnConnectionDiscard(raw, reason, cause) })
+	} else if cause != nil {
+		fmt.Printf("Discarding connection (%v): %v\n", reason, cause)
+	} else {
+		fmt.Printf("Discarding connection (%v)\n", reason)
+	}
+	return p.closeConn(raw, "Connection is closing because it was discarded")
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Do acquires a connection, runs fn with it and hands the connection back.
+// The connection is released when fn succeeds or fails with an application error, and
+// discarded when fn fails with a broken-connection error or panics (the panic is re-raised).
+// Retryable errors are retried on freshly dialed connections up to DoRetries times,
+// waiting out the pool's backoff between attempts.
+//
+// Parameters:
+//   - ctx: The context bounding acquisition and backoff waits.
+//   - fn: The callback using the connection.
+//
+// Returns:
+//   - The error returned by fn, or an acquisition or release error.
+func (p *ConnectionPool) Do(ctx context.Context, fn func(net.Conn) error) error {
+	fresh := false
+	cfg := p.settings()
+	for attempt := uint(1); ; attempt++ {
+		conn, err := p.getForDo(ctx, fresh)
+		if err != nil {
+			return err
+		}
+
+		err = p.run(conn, fn)
+		if err == nil {
+			return p.Release(conn)
+		}
+		p.ReleaseWithError(conn, err)
+		if !IsBrokenConn(err) {
+			return err
+		}
+
+		if attempt > cfg.doRetries || !IsRetryable(err) {
+			return err
+		}
+		fmt.Printf("Retrying on a fresh connection after: %v\n", err)
+
+		timer := time.NewTimer(cfg.backoff.NextRetry(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		fresh = true
+	}
+}
+
+// getForDo acquires the connection for an attempt of Do. Retries skip the idle
+// connections, which are likely as stale as the one that just failed.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - fresh: Whether a newly dialed connection is required.
+//
+// Returns:
+//   - The connection handle.
+//   - An error, if the acquisition fails.
+func (p *ConnectionPool) getForDo(ctx context.Context, fresh bool) (net.Conn, error) {
+	start := time.Now()
+	req := newRequest(ctx, fresh, "")
+	conn, err := p.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.checkout(conn, start, req), nil
+}
+
+// run calls fn, discarding the connection if fn panics.
+//
+// Parameters:
+//   - conn: The connection handle passed to fn.
+//   - fn: The callback using the connection.
+//
+// Returns:
+//   - The error returned by fn.
+func (p *ConnectionPool) run(conn net.Conn, fn func(net.Conn) error) error {
+	defer func() {
+		if r := recover(); r != nil {
+			p.discard(conn, DiscardPanic, fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+	}()
+	return fn(conn)
+}
@@ -0,0 +1,474 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults applied to the unset fields of an OutlierConfig.
+const (
+	DefaultOutlierInterval    = 10 * time.Second
+	DefaultOutlierMinRequests = 5
+	DefaultOutlierStdevFactor = 1.9
+	DefaultOutlierMinHosts    = 5
+	DefaultBaseEjectionTime   = 30 * time.Second
+	DefaultMaxEjectionTime    = 5 * time.Minute
+	DefaultMaxEjectionPercent = 10
+)
+
+// OutlierConfig configures the ejection of misbehaving endpoints from a pool with several Endpoints.
+// Every Interval each endpoint's error rate (failed dials, idle connections failing validation and
+// errors passed to ReleaseWithError, over all its outcomes) and latency (the time its connections are
+// held) are compared with those of the other endpoints. An endpoint further than StdevFactor standard
+// deviations above the mean, or failing at least FailureThreshold of the time, is ejected: no new
+// connections are dialed to it and its idle connections are closed. An ejected endpoint is probed once
+// its ejection time is over, by a dial and the health check's Probe if there is one, and returns if the
+// probe succeeds; each further ejection doubles the ejection time, up to MaxEjectionTime. The due
+// endpoints are probed concurrently, each within the health check's Timeout, or ConnTimeout without one.
+//
+// Among n endpoints none can stand more than sqrt(n-1) standard deviations above the mean, so the
+// standard deviation test only runs when at least MinHosts endpoints are judged in an interval, and
+// StdevFactor must stay below sqrt(MinHosts-1) for it to ever eject (2 with the default 5). Fewer
+// endpoints are only ejected by FailureThreshold.
+type OutlierConfig struct {
+	Interval           time.Duration // How often endpoints are judged (DefaultOutlierInterval if 0)
+	MinRequests        int           // Outcomes an endpoint needs within an interval to be judged (DefaultOutlierMinRequests if 0)
+	MinHosts           int           // Endpoints judged in an interval needed for the standard deviation test (DefaultOutlierMinHosts if 0)
+	StdevFactor        float64       // Standard deviations above the mean that make an outlier (DefaultOutlierStdevFactor if 0)
+	FailureThreshold   float64       // Error rate ejecting an endpoint whatever the others do, between 0 and 1; 0 disables
+	BaseEjectionTime   time.Duration // Ejection time of a first ejection (DefaultBaseEjectionTime if 0)
+	MaxEjectionTime    time.Duration // Longest ejection time (DefaultMaxEjectionTime if 0)
+	MaxEjectionPercent int           // Highest share of the endpoints ejected at once, in percent (DefaultMaxEjectionPercent if 0); one endpoint may always be ejected
+}
+
+// validate checks the outlier detection settings of a pool with endpoints endpoints.
+func (o *OutlierConfig) validate(endpoints int) []error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: outlier detection: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+	if endpoints < 2 {
+		invalid("needs at least two endpoints, got %d", endpoints)
+	}
+	if o.Interval < 0 || o.BaseEjectionTime < 0 || o.MaxEjectionTime < 0 {
+		invalid("durations must not be negative")
+	}
+	if o.MinRequests < 0 || o.MinHosts < 0 || o.StdevFactor < 0 {
+		invalid("min requests, min hosts and stdev factor must not be negative")
+	}
+	if o.FailureThreshold < 0 || o.FailureThreshold > 1 {
+		invalid("failure threshold must be between 0 and 1, got %v", o.FailureThreshold)
+	}
+	if o.MaxEjectionPercent < 0 || o.MaxEjectionPercent > 100 {
+		invalid("max ejection percent must be between 0 and 100, got %d", o.MaxEjectionPercent)
+	}
+	return errs
+}
+
+// withDefaults returns the settings with the unset fields filled in.
+func (o OutlierConfig) withDefaults() OutlierConfig {
+	if o.Interval == 0 {
+		o.Interval = DefaultOutlierInterval
+	}
+	if o.MinRequests == 0 {
+		o.MinRequests = DefaultOutlierMinRequests
+	}
+	if o.MinHosts == 0 {
+		o.MinHosts = DefaultOutlierMinHosts
+	}
+	if o.StdevFactor == 0 {
+		o.StdevFactor = DefaultOutlierStdevFactor
+	}
+	if o.BaseEjectionTime == 0 {
+		o.BaseEjectionTime = DefaultBaseEjectionTime
+	}
+	if o.MaxEjectionTime == 0 {
+		o.MaxEjectionTime = DefaultMaxEjectionTime
+	}
+	if o.MaxEjectionTime < o.BaseEjectionTime {
+		o.MaxEjectionTime = o.BaseEjectionTime
+	}
+	if o.MaxEjectionPercent == 0 {
+		o.MaxEjectionPercent = DefaultMaxEjectionPercent
+	}
+	return o
+}
+
+// EndpointStats reports the health of one endpoint of a pool.
+type EndpointStats struct {
+	Requests     uint64        // Outcomes recorded: dials, validations and releases
+	Failures     uint64        // Outcomes that failed
+	Latency      time.Duration // Average time connections were held during the last outlier detection interval
+	Ejected      bool          // Whether the endpoint is ejected
+	EjectedUntil time.Time     // When an ejected endpoint will be probed
+	Ejections    uint64        // Times the endpoint was ejected
+	Healthy      bool          // Whether the health check considers the endpoint healthy; true without a health check
+	LastProbe    time.Time     // When the health check last probed the endpoint
+	ProbeError   error         // The error of the last probe, or nil if it succeeded
+}
+
+// endpoint is one of the addresses a pool dials, with its health.
+type endpoint struct {
+	addr string
+
+	requests     atomic.Int64 // Outcomes during the interval
+	failures     atomic.Int64 // Failed outcomes during the interval
+	latencyNanos atomic.Int64 // Time connections were held during the interval
+	latencies    atomic.Int64 // Connections held during the interval
+
+	total        atomic.Uint64
+	failed       atomic.Uint64
+	latency      atomic.Int64 // Average hold time of the last interval
+	ejectedUntil atomic.Int64 // Unix nanoseconds; 0 while the endpoint is live
+	ejections    atomic.Uint64
+
+	multiplier int // Doublings of the next ejection time; owned by DetectOutliers
+
+	healthy atomic.Bool // Set by the health check; true until a probe fails Fall times
+	health  health
+}
+
+// record counts an outcome of the endpoint.
+//
+// Parameters:
+//   - failed: Whether the outcome is a failure.
+func (e *endpoint) record(failed bool) {
+	e.requests.Add(1)
+	e.total.Add(1)
+	if failed {
+		e.failures.Add(1)
+		e.failed.Add(1)
+	}
+}
+
+// observe records how long a connection to the endpoint was held.
+func (e *endpoint) observe(held time.Duration) {
+	e.latencyNanos.Add(int64(held))
+	e.latencies.Add(1)
+}
+
+// ejected reports whether the endpoint is out of the rotation.
+func (e *endpoint) ejected() bool {
+	return e.ejectedUntil.Load() != 0
+}
+
+// usable reports whether new connections may be dialed to the endpoint and idle ones kept.
+func (e *endpoint) usable() bool {
+	return e.healthy.Load() && !e.ejected()
+}
+
+// stats returns the endpoint's health.
+func (e *endpoint) stats() EndpointStats {
+	s := EndpointStats{
+		Requests:  e.total.Load(),
+		Failures:  e.failed.Load(),
+		Latency:   time.Duration(e.latency.Load()),
+		Ejections: e.ejections.Load(),
+		Healthy:   e.healthy.Load(),
+	}
+	e.health.mu.Lock()
+	s.LastProbe, s.ProbeError = e.health.lastProbe, e.health.probeErr
+	e.health.mu.Unlock()
+	if until := e.ejectedUntil.Load(); until != 0 {
+		s.Ejected = true
+		s.EjectedUntil = time.Unix(0, until)
+	}
+	return s
+}
+
+// newEndpoints creates the endpoints of a pool configured with c.
+func newEndpoints(c ConfigImpl) ([]*endpoint, map[string]*endpoint) {
+	list := []*endpoint{{addr: c.Address}}
+	for _, addr := range c.Endpoints {
+		list = append(list, &endpoint{addr: addr})
+	}
+	byAddr := make(map[string]*endpoint, len(list))
+	for _, e := range list {
+		e.healthy.Store(true)
+		byAddr[e.addr] = e
+	}
+	return list, byAddr
+}
+
+// pickEndpoint returns the endpoint to dial next, going round the healthy endpoints that are not
+// ejected. If every healthy endpoint is ejected, those are tried in turn.
+//
+// Returns:
+//   - The endpoint to dial, or nil if no endpoint is healthy.
+func (p *ConnectionPool) pickEndpoint() *endpoint {
+	n := uint64(len(p.endpoints))
+	start := p.nextEndpoint.Add(1) - 1
+	var fallback *endpoint
+	for i := range n {
+		e := p.endpoints[(start+i)%n]
+		if !e.healthy.Load() {
+			continue
+		}
+		if !e.ejected() {
+			return e
+		}
+		if fallback == nil {
+			fallback = e
+		}
+	}
+	return fallback
+}
+
+// endpointOf returns the endpoint a raw connection was dialed to.
+//
+// Parameters:
+//   - conn: A raw connection dialed by the pool.
+//
+// Returns:
+//   - The endpoint, or nil if the pool does not know conn.
+func (p *ConnectionPool) endpointOf(conn net.Conn) *endpoint {
+	return p.byAddr[p.info(conn).endpoint]
+}
+
+// settle records the outcome of a lease against the endpoint of its connection.
+//
+// Parameters:
+//   - l: The ended lease.
+//   - err: The error the caller reported, or nil.
+func (p *ConnectionPool) settle(l *lease, err error) {
+	if e := p.endpointOf(l.conn); e != nil {
+		e.observe(time.Since(l.acquiredAt))
+		e.record(err != nil)
+	}
+}
+
+// endpointStats returns the health of every endpoint.
+//
+// Returns:
+//   - The stats by address, or nil for a pool with a single endpoint and no health check.
+func (p *ConnectionPool) endpointStats() map[string]EndpointStats {
+	if len(p.endpoints) == 1 && p.settings().healthCheck == nil {
+		return nil
+	}
+	stats := make(map[string]EndpointStats, len(p.endpoints))
+	for _, e := range p.endpoints {
+		stats[e.addr] = e.stats()
+	}
+	return stats
+}
+
+// outlier is an endpoint that stands out during an interval.
+type outlier struct {
+	endpoint *endpoint
+	severity float64 // How far the endpoint stands out, to eject the worst first
+	reason   error
+}
+
+// judged is an endpoint's record over an interval.
+type judged struct {
+	endpoint *endpoint
+	errRate  float64
+	latency  float64 // Nanoseconds, or -1 if no connection was held
+}
+
+// DetectOutliers judges the endpoints every OutlierConfig.Interval, ejecting the outliers and
+// probing the ejected endpoints that are due, until the pool is closed.
+func (p *ConnectionPool) DetectOutliers() {
+	cfg := p.outliers
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+		p.detectOutliers(cfg, time.Now())
+	}
+}
+
+// detectOutliers runs one round of outlier detection.
+//
+// Parameters:
+//   - cfg: The outlier detection settings, with defaults applied.
+//   - now: The current time.
+func (p *ConnectionPool) detectOutliers(cfg OutlierConfig, now time.Time) {
+	var records []judged
+	var due []*endpoint
+	ejected := 0
+	for _, e := range p.endpoints {
+		requests, failures := e.requests.Swap(0), e.failures.Swap(0)
+		held, nanos := e.latencies.Swap(0), e.latencyNanos.Swap(0)
+		latency := -1.0
+		if held > 0 {
+			latency = float64(nanos) / float64(held)
+			e.latency.Store(int64(latency))
+		}
+
+		if until := e.ejectedUntil.Load(); until != 0 {
+			if now.UnixNano() < until {
+				ejected++
+			} else {
+				due = append(due, e)
+			}
+			continue
+		}
+		if e.multiplier > 0 {
+			e.multiplier--
+		}
+		if requests >= int64(cfg.MinRequests) {
+			records = append(records, judged{endpoint: e, errRate: float64(failures) / float64(requests), latency: latency})
+		}
+	}
+
+	// A slow probe must not hold up the others: probe the due endpoints together.
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i, e := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.probe(context.Background(), e.addr)
+		}()
+	}
+	wg.Wait()
+	for i, e := range due {
+		if errs[i] != nil {
+			p.eject(e, now, fmt.Errorf("probe failed: %w", errs[i]))
+			ejected++
+		} else {
+			p.restore(e)
+		}
+	}
+
+	allowed := max(1, len(p.endpoints)*cfg.MaxEjectionPercent/100) - ejected
+	for _, o := range findOutliers(records, cfg) {
+		if allowed <= 0 {
+			return
+		}
+		p.eject(o.endpoint, now, o.reason)
+		allowed--
+	}
+}
+
+// findOutliers picks the endpoints whose error rate or latency stands out, the worst first.
+// The standard deviation test needs cfg.MinHosts values; FailureThreshold applies to any number.
+//
+// Parameters:
+//   - records: The endpoints judged during the interval.
+//   - cfg: The outlier detection settings.
+//
+// Returns:
+//   - The outliers, by decreasing severity.
+func findOutliers(records []judged, cfg OutlierConfig) []outlier {
+	found := make(map[*endpoint]outlier)
+	add := func(o outlier) {
+		if prev, ok := found[o.endpoint]; !ok || o.severity > prev.severity {
+			found[o.endpoint] = o
+		}
+	}
+
+	var errRates, latencies []float64
+	for _, r := range records {
+		errRates = append(errRates, r.errRate)
+		if r.latency >= 0 {
+			latencies = append(latencies, r.latency)
+		}
+	}
+	errMean, errStdev := meanStdev(errRates, cfg.MinHosts)
+	latMean, latStdev := meanStdev(latencies, cfg.MinHosts)
+	for _, r := range records {
+		if cfg.FailureThreshold > 0 && r.errRate >= cfg.FailureThreshold {
+			add(outlier{r.endpoint, math.Inf(1), fmt.Errorf("error rate %.0f%% reached the failure threshold", r.errRate*100)})
+		}
+		if errStdev > 0 && r.errRate > errMean+cfg.StdevFactor*errStdev {
+			add(outlier{r.endpoint, (r.errRate - errMean) / errStdev, fmt.Errorf("error rate %.0f%% is an outlier (mean %.0f%%)", r.errRate*100, errMean*100)})
+		}
+		if latStdev > 0 && r.latency >= 0 && r.latency > latMean+cfg.StdevFactor*latStdev {
+			add(outlier{r.endpoint, (r.latency - latMean) / latStdev, fmt.Errorf("latency %v is an outlier (mean %v)",
+				time.Duration(r.latency).Round(time.Microsecond), time.Duration(latMean).Round(time.Microsecond))})
+		}
+	}
+
+	outliers := make([]outlier, 0, len(found))
+	for _, o := range found {
+		outliers = append(outliers, o)
+	}
+	sort.Slice(outliers, func(i, j int) bool { return outliers[i].severity > outliers[j].severity })
+	return outliers
+}
+
+// meanStdev returns the mean and population standard deviation of values, or zeros, which disable
+// the test, if there are fewer than minValues of them.
+func meanStdev(values []float64, minValues int) (float64, float64) {
+	if len(values) == 0 || len(values) < minValues {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// eject takes an endpoint out of the rotation and closes its idle connections.
+//
+// Parameters:
+//   - e: The endpoint.
+//   - now: The current time.
+//   - reason: Why the endpoint is ejected.
+func (p *ConnectionPool) eject(e *endpoint, now time.Time, reason error) {
+	cfg := p.outliers
+	d := cfg.BaseEjectionTime << min(e.multiplier, 30)
+	if d <= 0 || d > cfg.MaxEjectionTime {
+		d = cfg.MaxEjectionTime
+	}
+	e.multiplier++
+	e.ejectedUntil.Store(now.Add(d).UnixNano())
+	e.ejections.Add(1)
+
+	ev := Event{Type: EventEndpointEject, Address: e.addr, Duration: d, Err: reason}
+	p.stamp(&ev)
+	if p.Hooks.OnEndpointEject != nil {
+		p.callHook("OnEndpointEject", func() { p.Hooks.OnEndpointEject(ev) })
+	} else {
+		fmt.Printf("Ejecting endpoint %s of pool %s for %v: %v\n", e.addr, p.Name, d, reason)
+	}
+	p.deliver(ev)
+
+	p.closeIdleOf(e, "Closing idle connection to an ejected endpoint")
+}
+
+// closeIdleOf closes the idle connections to an endpoint.
+//
+// Parameters:
+//   - e: The endpoint.
+//   - reason: The message logged for each closed connection.
+func (p *ConnectionPool) closeIdleOf(e *endpoint, reason string) {
+	for _, idle := range p.idle.RemoveIf(func(c IdleConn) bool { return p.endpointOf(c.Conn) == e }) {
+		p.closeConn(idle.Conn, reason)
+	}
+}
+
+// restore puts an ejected endpoint back into the rotation.
+//
+// Parameters:
+//   - e: The endpoint.
+func (p *ConnectionPool) restore(e *endpoint) {
+	e.ejectedUntil.Store(0)
+	ev := Event{Type: EventEndpointReturn, Address: e.addr}
+	p.stamp(&ev)
+	if p.Hooks.OnEndpointReturn != nil {
+		p.callHook("OnEndpointReturn", func() { p.Hooks.OnEndpointReturn(ev) })
+	} else {
+		fmt.Printf("Returning endpoint %s to pool %s\n", e.addr, p.Name)
+	}
+	p.deliver(ev)
+	p.wakeWaiters()
+}
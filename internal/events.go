@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies a pool lifecycle event.
+type EventType string
+
+const (
+	EventConnectionCreate  EventType = "connection_create"  // A connection was dialed; Duration is the dial time
+	EventConnectionError   EventType = "connection_error"   // Dialing failed; Err is the final error
+	EventConnectionAcquire EventType = "connection_acquire" // A connection was checked out; Duration is the wait
+	EventConnectionRelease EventType = "connection_release" // A connection went back to the pool; Duration is the hold time
+	EventConnectionClose   EventType = "connection_close"   // The pool closed a connection
+	EventConnectionDiscard EventType = "connection_discard" // A connection was discarded; Reason and Err say why
+	EventConnectionLeak    EventType = "connection_leak"    // A connection was reported as leaked; Duration is the hold time
+	EventDialAttempt       EventType = "dial_attempt"       // A dial attempt finished; Err is set if it failed
+	EventDialRetry         EventType = "dial_retry"         // A failed dial is about to be retried after Delay
+	EventLimitChange       EventType = "limit_change"       // The autoscaler changed MaxConnections to Limit; Duration is the latency
+	EventEndpointEject     EventType = "endpoint_eject"     // Address was ejected as an outlier for Duration; Err says why
+	EventEndpointReturn    EventType = "endpoint_return"    // Address passed its probe and is back in the rotation
+	EventEndpointHealthy   EventType = "endpoint_healthy"   // The health check marked Address healthy
+	EventEndpointUnhealthy EventType = "endpoint_unhealthy" // The health check marked Address unhealthy; Err is the last probe error
+)
+
+// Event describes something that happened in a pool. It is also the payload of the
+// per-event hooks (OnEvent, OnDialAttempt, OnDialRetry).
+type Event struct {
+	Type     EventType     // What happened
+	Pool     string        // Name of the pool
+	Address  string        // Endpoint involved
+	Time     time.Time     // When it happened
+	Conn     net.Conn      // The connection involved, if any
+	ConnID   uint64        // ID of the connection involved, see ConnInfo; 0 if there is none
+	Duration time.Duration // Event-specific duration, see EventType; for dial events, the time since dialing started
+	Attempt  uint          // Dial attempt number, starting at 1, for dial and connection error events
+	Delay    time.Duration // Backoff delay before the next attempt, for EventDialRetry
+	Reason   DiscardReason // Why a connection was discarded
+	Limit    int           // The new connection limit, for EventLimitChange
+	Err      error         // The error involved, if any
+}
+
+// EventFilter selects the events a subscriber receives. A nil filter accepts every event.
+type EventFilter func(e Event) bool
+
+// EventTypes returns a filter accepting only the given event types.
+//
+// Parameters:
+//   - types: The event types to accept.
+//
+// Returns:
+//   - An EventFilter.
+func EventTypes(types ...EventType) EventFilter {
+	set := make(map[EventType]bool, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return func(e Event) bool {
+		return set[e.Type]
+	}
+}
+
+// SubscribeOptions controls how events are queued for a subscriber.
+type SubscribeOptions struct {
+	// Buffer is the number of events queued for the subscriber (defaults to 64).
+	Buffer int
+	// Blocking makes publishers wait for room in a full queue instead of dropping the event.
+	// A slow blocking subscriber slows down Get and Release.
+	Blocking bool
+}
+
+// defaultEventBuffer is the queue size used when SubscribeOptions.Buffer is not set.
+const defaultEventBuffer = 64
+
+// subscriber is a single event consumer with its own ordered queue.
+type subscriber struct {
+	filter   EventFilter
+	blocking bool
+	ch       chan Event
+	done     chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+}
+
+// eventBus fans events out to subscribers.
+type eventBus struct {
+	mu      sync.Mutex
+	subs    atomic.Pointer[[]*subscriber] // Copy-on-write, so publishing takes no lock
+	dropped atomic.Uint64
+}
+
+// Subscribe registers a subscriber and returns the channel its events are delivered on, in order.
+// Events that do not fit in the queue are dropped and counted in Stats.EventsDropped.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//   - opts: Queueing options.
+//
+// Returns:
+//   - The channel events are delivered on. It is closed by Unsubscribe.
+func (p *ConnectionPool) Subscribe(filter EventFilter, opts SubscribeOptions) <-chan Event {
+	return p.events.add(filter, opts).ch
+}
+
+// Unsubscribe removes the subscriber that owns ch and closes ch.
+//
+// Parameters:
+//   - ch: A channel returned by Subscribe.
+func (p *ConnectionPool) Unsubscribe(ch <-chan Event) {
+	p.events.remove(func(s *subscriber) bool {
+		return s.ch == ch
+	})
+}
+
+// AddHook calls fn for every event accepted by filter. Calls happen in order on a dedicated goroutine,
+// so fn never runs on the Get or Release path.
+//
+// Parameters:
+//   - filter: Selects the events to deliver, or nil for all.
+//   - opts: Queueing options.
+//   - fn: The callback.
+//
+// Returns:
+//   - A function that removes the hook.
+func (p *ConnectionPool) AddHook(filter EventFilter, opts SubscribeOptions, fn func(e Event)) func() {
+	sub := p.events.add(filter, opts)
+	go func() {
+		for e := range sub.ch {
+			p.callHook("AddHook", func() { fn(e) })
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.events.remove(func(s *subscriber) bool {
+				return s == sub
+			})
+		})
+	}
+}
+
+// add registers a new subscriber.
+func (b *eventBus) add(filter EventFilter, opts SubscribeOptions) *subscriber {
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultEventBuffer
+	}
+	sub := &subscriber{
+		filter:   filter,
+		blocking: opts.Blocking,
+		ch:       make(chan Event, opts.Buffer),
+		done:     make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var subs []*subscriber
+	if cur := b.subs.Load(); cur != nil {
+		subs = append(subs, *cur...)
+	}
+	subs = append(subs, sub)
+	b.subs.Store(&subs)
+	return sub
+}
+
+// remove unregisters the subscribers matching match and closes their channels.
+func (b *eventBus) remove(match func(s *subscriber) bool) {
+	b.mu.Lock()
+	var kept, removed []*subscriber
+	if cur := b.subs.Load(); cur != nil {
+		for _, s := range *cur {
+			if match(s) {
+				removed = append(removed, s)
+			} else {
+				kept = append(kept, s)
+			}
+		}
+	}
+	b.subs.Store(&kept)
+	b.mu.Unlock()
+
+	for _, s := range removed {
+		close(s.done)
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	}
+}
+
+// publish delivers e to every interested subscriber. Filters run through guard.
+func (b *eventBus) publish(e Event, guard func(name string, fn func()) error) {
+	subs := b.subs.Load()
+	if subs == nil {
+		return
+	}
+	for _, s := range *subs {
+		if s.filter != nil {
+			accept := false
+			if guard("EventFilter", func() { accept = s.filter(e) }) != nil || !accept {
+				continue
+			}
+		}
+		s.deliver(e, &b.dropped)
+	}
+}
+
+// deliver queues e, waiting for room only in blocking mode.
+func (s *subscriber) deliver(e Event, dropped *atomic.Uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	if s.blocking {
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+		return
+	}
+	select {
+	case s.ch <- e:
+	default:
+		dropped.Add(1)
+	}
+}
+
+// publish stamps e with the pool name, address and time, passes it to the OnEvent hook
+// and hands it to the subscribers.
+//
+// Parameters:
+//   - e: The event to publish.
+func (p *ConnectionPool) publish(e Event) {
+	if p.events.subs.Load() == nil && p.Hooks.OnEvent == nil {
+		return
+	}
+	p.stamp(&e)
+	p.deliver(e)
+}
+
+// publishDial reports a dial attempt or retry to its dedicated hook, then publishes it.
+//
+// Parameters:
+//   - e: An EventDialAttempt or EventDialRetry event.
+func (p *ConnectionPool) publishDial(e Event) {
+	p.stamp(&e)
+	if e.Type == EventDialRetry && p.Hooks.OnDialRetry != nil {
+		p.callHook("OnDialRetry", func() { p.Hooks.OnDialRetry(e) })
+	} else if e.Type == EventDialAttempt && p.Hooks.OnDialAttempt != nil {
+		p.callHook("OnDialAttempt", func() { p.Hooks.OnDialAttempt(e) })
+	}
+	p.deliver(e)
+}
+
+// stamp fills in the fields every event carries.
+//
+// Parameters:
+//   - e: The event to complete.
+func (p *ConnectionPool) stamp(e *Event) {
+	e.Pool = p.Name
+	if e.Address == "" {
+		e.Address = p.Address
+	}
+	e.Time = time.Now()
+	if e.Conn != nil && e.ConnID == 0 {
+		if info, ok := p.ConnInfo(e.Conn); ok {
+			e.ConnID = info.ID
+		}
+	}
+}
+
+// deliver passes a stamped event to the OnEvent hook and the subscribers.
+//
+// Parameters:
+//   - e: The stamped event.
+func (p *ConnectionPool) deliver(e Event) {
+	if p.Hooks.OnEvent != nil {
+		p.callHook("OnEvent", func() { p.Hooks.OnEvent(e) })
+	}
+	p.events.publish(e, p.callHook)
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrHookPanic is matched by the error reported when a user hook panics.
+var ErrHookPanic = errors.New("tcppool: hook panicked")
+
+// HookPanicError describes a panic recovered from a user hook.
+type HookPanicError struct {
+	Hook  string // Name of the hook that panicked
+	Value any    // Value passed to panic
+	Stack string // Stack of the panicking goroutine
+}
+
+func (e *HookPanicError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", ErrHookPanic, e.Hook, e.Value)
+}
+
+func (e *HookPanicError) Unwrap() error {
+	return ErrHookPanic
+}
+
+// callHook runs fn, recovering a panic so that a faulty hook cannot unwind through Get, Release
+// or the pool's background goroutines. The panic is reported through OnHookPanic (or logged)
+// and re-raised only when RepanicHooks is set.
+//
+// Parameters:
+//   - name: The hook name used in the report.
+//   - fn: The hook invocation.
+//
+// Returns:
+//   - A *HookPanicError if fn panicked, nil otherwise.
+func (p *ConnectionPool) callHook(name string, fn func()) error {
+	return guardHook(&p.Hooks, p.RepanicHooks, name, fn)
+}
+
+// callHookErr runs an error-returning hook like callHook.
+//
+// Parameters:
+//   - name: The hook name used in the report.
+//   - fn: The hook invocation.
+//
+// Returns:
+//   - The error returned by fn, or a *HookPanicError if fn panicked.
+func (p *ConnectionPool) callHookErr(name string, fn func() error) error {
+	var err error
+	if panicErr := p.callHook(name, func() { err = fn() }); panicErr != nil {
+		return panicErr
+	}
+	return err
+}
+
+// rejectReason returns the discard reason for a connection rejected by a veto hook.
+//
+// Parameters:
+//   - err: The error returned by the hook.
+//
+// Returns:
+//   - DiscardPanic if the hook panicked, DiscardRejected otherwise.
+func rejectReason(err error) DiscardReason {
+	if errors.Is(err, ErrHookPanic) {
+		return DiscardPanic
+	}
+	return DiscardRejected
+}
+
+// guardHook implements callHook for callers that do not have a pool yet.
+//
+// Parameters:
+//   - hooks: The hooks to report a panic to.
+//   - repanic: Whether to re-raise the panic after reporting it.
+//   - name: The hook name used in the report.
+//   - fn: The hook invocation.
+//
+// Returns:
+//   - A *HookPanicError if fn panicked, nil otherwise.
+func guardHook(hooks *PoolHooks, repanic bool, name string, fn func()) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		panicErr := &HookPanicError{Hook: name, Value: r, Stack: string(debug.Stack())}
+		reportHookPanic(hooks, panicErr)
+		if repanic {
+			panic(r)
+		}
+		err = panicErr
+	}()
+	fn()
+	return nil
+}
+
+// reportHookPanic forwards a hook panic to OnHookPanic, or logs it.
+// A panic in OnHookPanic itself is logged and swallowed.
+//
+// Parameters:
+//   - hooks: The hooks to report to.
+//   - err: The recovered panic.
+func reportHookPanic(hooks *PoolHooks, err *HookPanicError) {
+	if hooks.OnHookPanic != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("OnHookPanic panicked while reporting %v: %v\n", err, r)
+			}
+		}()
+		hooks.OnHookPanic(err)
+		return
+	}
+	fmt.Printf("Recovered from panic in hook: %v\n%s\n", err, err.Stack)
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyEndpoint is returned when a connection must be dialed while every endpoint of the
+// pool has been marked unhealthy by the health check.
+var ErrNoHealthyEndpoint = errors.New("tcppool: no healthy endpoint")
+
+// Defaults applied to the unset fields of a HealthCheckConfig.
+const (
+	DefaultHealthInterval = 5 * time.Second
+	DefaultHealthTimeout  = time.Second
+	DefaultHealthRise     = 2
+	DefaultHealthFall     = 3
+)
+
+// HealthCheckConfig configures the active health check of a pool's endpoints. Every Interval each
+// endpoint is probed: it is dialed and, if Probe is set, the probe runs on the new connection. An
+// endpoint is marked unhealthy after Fall consecutive failed probes, and healthy again after Rise
+// consecutive successful ones. No connections are dialed to an unhealthy endpoint and its idle
+// connections are closed, so Get skips it without paying for a failed dial and backoff.
+type HealthCheckConfig struct {
+	Interval time.Duration // How often endpoints are probed (DefaultHealthInterval if 0)
+	Timeout  time.Duration // Time allowed for a probe, dial included (DefaultHealthTimeout if 0)
+	Rise     int           // Consecutive successful probes marking an endpoint healthy (DefaultHealthRise if 0)
+	Fall     int           // Consecutive failed probes marking an endpoint unhealthy (DefaultHealthFall if 0)
+
+	// Probe checks a freshly dialed connection, e.g. by sending a protocol ping and reading the
+	// reply. A non-nil error fails the probe. If nil, a successful dial passes.
+	Probe func(ctx context.Context, conn net.Conn) error
+}
+
+// validate checks the health check settings.
+func (h *HealthCheckConfig) validate() []error {
+	var errs []error
+	if h.Interval < 0 || h.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("%w: health check: durations must not be negative", ErrInvalidConfig))
+	}
+	if h.Rise < 0 || h.Fall < 0 {
+		errs = append(errs, fmt.Errorf("%w: health check: rise and fall must not be negative", ErrInvalidConfig))
+	}
+	return errs
+}
+
+// withDefaults returns the settings with the unset fields filled in.
+func (h HealthCheckConfig) withDefaults() HealthCheckConfig {
+	if h.Interval == 0 {
+		h.Interval = DefaultHealthInterval
+	}
+	if h.Timeout == 0 {
+		h.Timeout = DefaultHealthTimeout
+	}
+	if h.Rise == 0 {
+		h.Rise = DefaultHealthRise
+	}
+	if h.Fall == 0 {
+		h.Fall = DefaultHealthFall
+	}
+	return h
+}
+
+// health is the state the health check keeps about an endpoint.
+type health struct {
+	mu        sync.Mutex
+	lastProbe time.Time
+	probeErr  error
+	rise      int // Consecutive successful probes
+	fall      int // Consecutive failed probes
+}
+
+// CheckHealth probes every endpoint right away and then every HealthCheckConfig.Interval,
+// until the pool is closed. The settings are read again before each round; when Reconfigure
+// changes them, the pending wait restarts with the new interval.
+func (p *ConnectionPool) CheckHealth() {
+	ticker := time.NewTicker(p.settings().healthCheck.Interval)
+	defer ticker.Stop()
+	for {
+		cfg := *p.settings().healthCheck
+
+		var wg sync.WaitGroup
+		for _, e := range p.endpoints {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.checkEndpoint(e, cfg)
+			}()
+		}
+		wg.Wait()
+
+		if !p.awaitHealthCheck(ticker) {
+			return
+		}
+	}
+}
+
+// awaitHealthCheck waits for the next round of probes, restarting the wait with the new interval
+// whenever Reconfigure changes the health check.
+//
+// Parameters:
+//   - ticker: The ticker of the rounds.
+//
+// Returns:
+//   - Whether to run the next round; false once the pool is closed.
+func (p *ConnectionPool) awaitHealthCheck(ticker *time.Ticker) bool {
+	for {
+		select {
+		case <-ticker.C:
+			return true
+		case <-p.rechecked:
+			ticker.Reset(p.settings().healthCheck.Interval)
+		case <-p.done:
+			return false
+		}
+	}
+}
+
+// checkEndpoint probes an endpoint and applies the rise and fall thresholds.
+//
+// Parameters:
+//   - e: The endpoint.
+//   - cfg: The health check settings, with defaults applied.
+func (p *ConnectionPool) checkEndpoint(e *endpoint, cfg HealthCheckConfig) {
+	err := p.probe(context.Background(), e.addr)
+
+	e.health.mu.Lock()
+	e.health.lastProbe = time.Now()
+	e.health.probeErr = err
+	var change bool
+	if err == nil {
+		e.health.rise++
+		e.health.fall = 0
+		change = !e.healthy.Load() && e.health.rise >= cfg.Rise
+	} else {
+		e.health.fall++
+		e.health.rise = 0
+		change = e.healthy.Load() && e.health.fall >= cfg.Fall
+	}
+	if change {
+		e.healthy.Store(err == nil)
+	}
+	e.health.mu.Unlock()
+
+	if !change {
+		return
+	}
+	ev := Event{Type: EventEndpointHealthy, Address: e.addr}
+	if err != nil {
+		ev = Event{Type: EventEndpointUnhealthy, Address: e.addr, Err: err}
+	}
+	p.stamp(&ev)
+	if p.Hooks.OnHealthChange != nil {
+		p.callHook("OnHealthChange", func() { p.Hooks.OnHealthChange(ev) })
+	} else if err != nil {
+		fmt.Printf("Endpoint %s of pool %s is unhealthy: %v\n", e.addr, p.Name, err)
+	} else {
+		fmt.Printf("Endpoint %s of pool %s is healthy\n", e.addr, p.Name)
+	}
+	p.deliver(ev)
+
+	if err != nil {
+		p.closeIdleOf(e, "Closing idle connection to an unhealthy endpoint")
+	} else {
+		p.wakeWaiters()
+	}
+}
+
+// probe checks that an endpoint accepts connections by dialing it once and, if the health check
+// has a Probe, running it on the connection, within the health check's Timeout.
+//
+// Parameters:
+//   - ctx: The context bounding the probe.
+//   - addr: The endpoint's address.
+//
+// Returns:
+//   - An error, if the dial or the probe fails.
+func (p *ConnectionPool) probe(ctx context.Context, addr string) error {
+	cfg := p.settings()
+	check := cfg.healthCheck
+	if check != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+	conn, err := p.dialOnce(ctx, cfg.connTimeout, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if check != nil && check.Probe != nil {
+		return p.callHookErr("Probe", func() error { return check.Probe(ctx, conn) })
+	}
+	return nil
+}
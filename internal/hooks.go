@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"net"
 )
 
@@ -12,4 +13,16 @@ type PoolHooks struct {
 	OnConnectionError   func(err error)
 	OnPoolCreate        func(c ConfigImpl)
 	OnPoolCreateError   func(err error)
+	OnConnectionLeak    func(info LeakInfo)
+	OnConnectionDiscard func(conn net.Conn, reason DiscardReason, err error)
+	OnConnect           func(ctx context.Context, conn net.Conn) error
+	OnBorrow            func(ctx context.Context, conn net.Conn) error
+	OnReturn            func(conn net.Conn) error
+	OnHookPanic         func(err *HookPanicError)
+	OnDialAttempt       func(e Event)
+	OnDialRetry         func(e Event)
+	OnEvent             func(e Event)
+	OnEndpointEject     func(e Event)
+	OnEndpointReturn    func(e Event)
+	OnHealthChange      func(e Event)
 }
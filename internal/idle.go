@@ -0,0 +1,504 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// IdleOrder selects which idle connection Get reuses first and which one is retired first.
+type IdleOrder string
+
+const (
+	// IdleLIFO reuses the most recently released connection, so that under low load the
+	// least recently used ones stay idle, expire and the pool shrinks. This is the default.
+	// The idle connections are kept in shards, each strictly LIFO (see ShardedStore).
+	IdleLIFO IdleOrder = "lifo"
+	// IdleFIFO reuses the least recently released connection, rotating evenly through all of
+	// them, e.g. to spread requests over load-balanced backends.
+	IdleFIFO IdleOrder = "fifo"
+	// IdleRetireOldest reuses the most recently dialed connection and retires the longest-lived
+	// ones first, so that old connections are gradually replaced.
+	IdleRetireOldest IdleOrder = "retire_oldest"
+)
+
+// valid reports whether o is a known order; the empty order means IdleLIFO.
+func (o IdleOrder) valid() bool {
+	switch o {
+	case "", IdleLIFO, IdleFIFO, IdleRetireOldest:
+		return true
+	}
+	return false
+}
+
+// IdleConn is an idle connection with the times its position in an IdleStore depends on.
+type IdleConn struct {
+	Conn    net.Conn
+	Since   time.Time // When the connection became idle
+	Created time.Time // When the connection was dialed
+	Key     string    // Session key the connection was last used with, see GetForKey
+}
+
+// IdleStore holds a pool's idle connections. Implementations are safe for concurrent use.
+type IdleStore interface {
+	// Push adds an idle connection. It returns false if the store is full.
+	Push(c IdleConn) bool
+	// Pop removes the connection to hand out next. It returns false if the store is empty.
+	Pop() (IdleConn, bool)
+	// PopColdest removes the connection to retire next. It returns false if the store is empty.
+	PopColdest() (IdleConn, bool)
+	// RemoveIf removes the connections matching match and returns them, coldest first.
+	RemoveIf(match func(c IdleConn) bool) []IdleConn
+	// OldestSince returns the earliest Since of the idle connections. It returns false if the store is empty.
+	OldestSince() (time.Time, bool)
+	// Len returns the number of idle connections.
+	Len() int
+}
+
+// NewIdleStore creates the store implementing order.
+// LIFO uses a ShardedStore, which finds the hottest connection across its shards; the other
+// orders need a single global order and use an OrderedStore.
+//
+// Parameters:
+//   - order: The idle ordering policy.
+//
+// Returns:
+//   - The IdleStore.
+func NewIdleStore(order IdleOrder) IdleStore {
+	if order == IdleFIFO || order == IdleRetireOldest {
+		return NewOrderedStore(order)
+	}
+	return NewShardedStore(0)
+}
+
+// orderedConns is a list of idle connections sorted from coldest to hottest by a key.
+// It is not safe for concurrent use.
+type orderedConns struct {
+	key     func(c IdleConn) time.Time
+	bySince bool // Whether key is idleSince, so the coldest connection has the earliest Since
+	conns   []IdleConn
+}
+
+// insert adds c at its place, searching from the hot end where new connections usually go.
+func (l *orderedConns) insert(c IdleConn) {
+	k := l.key(c)
+	i := len(l.conns)
+	for i > 0 && l.key(l.conns[i-1]).After(k) {
+		i--
+	}
+	l.conns = append(l.conns, IdleConn{})
+	copy(l.conns[i+1:], l.conns[i:])
+	l.conns[i] = c
+}
+
+// popFront removes the coldest connection.
+func (l *orderedConns) popFront() (IdleConn, bool) {
+	if len(l.conns) == 0 {
+		return IdleConn{}, false
+	}
+	c := l.conns[0]
+	l.conns[0] = IdleConn{}
+	l.conns = l.conns[1:]
+	return c, true
+}
+
+// popBack removes the hottest connection.
+func (l *orderedConns) popBack() (IdleConn, bool) {
+	last := len(l.conns) - 1
+	if last < 0 {
+		return IdleConn{}, false
+	}
+	c := l.conns[last]
+	l.conns[last] = IdleConn{}
+	l.conns = l.conns[:last]
+	return c, true
+}
+
+// removeIf removes the matching connections, keeping the order of the others.
+func (l *orderedConns) removeIf(match func(c IdleConn) bool) []IdleConn {
+	var removed []IdleConn
+	kept := l.conns[:0]
+	for _, c := range l.conns {
+		if match(c) {
+			removed = append(removed, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	for i := len(kept); i < len(l.conns); i++ {
+		l.conns[i] = IdleConn{}
+	}
+	l.conns = kept
+	return removed
+}
+
+// oldestSince returns the earliest Since in the list.
+func (l *orderedConns) oldestSince() (time.Time, bool) {
+	if len(l.conns) == 0 {
+		return time.Time{}, false
+	}
+	if l.bySince {
+		return l.conns[0].Since, true
+	}
+	oldest := l.conns[0].Since
+	for _, c := range l.conns[1:] {
+		if c.Since.Before(oldest) {
+			oldest = c.Since
+		}
+	}
+	return oldest, true
+}
+
+// idleSince and createdAt are the keys of the idle orders.
+func idleSince(c IdleConn) time.Time { return c.Since }
+func createdAt(c IdleConn) time.Time { return c.Created }
+
+// OrderedStore is an IdleStore keeping every connection in one list under one lock, for the
+// orders that need a global order: FIFO hands out the least recently released connection and
+// retire-oldest the most recently dialed one. Both retire from the other end of the list.
+type OrderedStore struct {
+	mu     sync.Mutex
+	list   orderedConns
+	newest bool // Whether Pop takes the hot end of the list
+	n      atomic.Int64
+}
+
+// NewOrderedStore creates an OrderedStore for IdleFIFO or IdleRetireOldest.
+//
+// Parameters:
+//   - order: The idle ordering policy.
+//
+// Returns:
+//   - A pointer to the created OrderedStore.
+func NewOrderedStore(order IdleOrder) *OrderedStore {
+	switch order {
+	case IdleFIFO:
+		return &OrderedStore{list: orderedConns{key: idleSince, bySince: true}}
+	case IdleRetireOldest:
+		return &OrderedStore{list: orderedConns{key: createdAt}, newest: true}
+	default:
+		panic(fmt.Sprintf("tcppool: no ordered store for idle order %q", order))
+	}
+}
+
+// Push adds an idle connection. It always succeeds.
+func (s *OrderedStore) Push(c IdleConn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.insert(c)
+	s.n.Store(int64(len(s.list.conns)))
+	return true
+}
+
+// Pop removes the connection to hand out next.
+func (s *OrderedStore) Pop() (IdleConn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var c IdleConn
+	var ok bool
+	if s.newest {
+		c, ok = s.list.popBack()
+	} else {
+		c, ok = s.list.popFront()
+	}
+	s.n.Store(int64(len(s.list.conns)))
+	return c, ok
+}
+
+// PopColdest removes the connection to retire next.
+func (s *OrderedStore) PopColdest() (IdleConn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.list.popFront()
+	s.n.Store(int64(len(s.list.conns)))
+	return c, ok
+}
+
+// RemoveIf removes the connections matching match, coldest first.
+func (s *OrderedStore) RemoveIf(match func(c IdleConn) bool) []IdleConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := s.list.removeIf(match)
+	s.n.Store(int64(len(s.list.conns)))
+	return removed
+}
+
+// OldestSince returns the earliest Since of the idle connections.
+func (s *OrderedStore) OldestSince() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.oldestSince()
+}
+
+// Len returns the number of idle connections.
+func (s *OrderedStore) Len() int {
+	return int(s.n.Load())
+}
+
+// ChannelStore is an IdleStore backed by a buffered channel, handing out and retiring the
+// connection that was pushed first. Every Get and Release goes through the one channel.
+type ChannelStore struct {
+	ch chan IdleConn
+}
+
+// NewChannelStore creates a ChannelStore holding up to capacity connections.
+//
+// Parameters:
+//   - capacity: The maximum number of idle connections.
+//
+// Returns:
+//   - A pointer to the created ChannelStore.
+func NewChannelStore(capacity int) *ChannelStore {
+	return &ChannelStore{ch: make(chan IdleConn, capacity)}
+}
+
+// Push adds an idle connection, or returns false if the channel is full.
+func (s *ChannelStore) Push(c IdleConn) bool {
+	select {
+	case s.ch <- c:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pop removes the oldest idle connection, or returns false if there is none.
+func (s *ChannelStore) Pop() (IdleConn, bool) {
+	select {
+	case c := <-s.ch:
+		return c, true
+	default:
+		return IdleConn{}, false
+	}
+}
+
+// PopColdest is Pop: the channel has a single order.
+func (s *ChannelStore) PopColdest() (IdleConn, bool) {
+	return s.Pop()
+}
+
+// RemoveIf cycles through the channel once, removing the connections matching match.
+func (s *ChannelStore) RemoveIf(match func(c IdleConn) bool) []IdleConn {
+	var removed []IdleConn
+	for i, n := 0, len(s.ch); i < n; i++ {
+		c, ok := s.Pop()
+		if !ok {
+			break
+		}
+		if match(c) || !s.Push(c) {
+			removed = append(removed, c)
+		}
+	}
+	return removed
+}
+
+// OldestSince cycles through the channel once to find the earliest Since.
+func (s *ChannelStore) OldestSince() (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	s.RemoveIf(func(c IdleConn) bool {
+		if !found || c.Since.Before(oldest) {
+			oldest, found = c.Since, true
+		}
+		return false
+	})
+	return oldest, found
+}
+
+// Len returns the number of idle connections.
+func (s *ChannelStore) Len() int {
+	return len(s.ch)
+}
+
+// ShardedStore is an unbounded IdleStore split into independently locked LIFO stacks.
+// Push and Pop each pick a random shard, so concurrent Gets and releases rarely touch the same
+// lock. Pop takes the most recently released connection of its shard, and only when that shard
+// is empty steals the most recently released one of the others, found without locking them.
+// The order is therefore LIFO within each shard but only roughly LIFO overall; the coldest
+// connection across all shards is still the first to be retired.
+type ShardedStore struct {
+	shards []idleShard
+}
+
+// cacheLineSize is the size of a CPU cache line on the common architectures.
+const cacheLineSize = 64
+
+// idleShardState is the state of one stack of a ShardedStore.
+type idleShardState struct {
+	mu   sync.Mutex
+	n    atomic.Int64 // len(list.conns), readable without the lock
+	top  atomic.Int64 // Since of the hottest connection in UnixNano, readable without the lock
+	list orderedConns
+}
+
+// idleShard is an idleShardState padded to a whole number of cache lines, so that the shards,
+// laid out one after the other, do not share lines.
+type idleShard struct {
+	idleShardState
+	_ [(cacheLineSize - unsafe.Sizeof(idleShardState{})%cacheLineSize) % cacheLineSize]byte
+}
+
+// update publishes the shard's length and top after its list changed. The caller holds mu.
+func (shard *idleShard) update() {
+	shard.n.Store(int64(len(shard.list.conns)))
+	if last := len(shard.list.conns) - 1; last >= 0 {
+		top := int64(math.MinInt64) // A zero Since is older than any other, and UnixNano cannot represent it
+		if since := shard.list.conns[last].Since; !since.IsZero() {
+			top = since.UnixNano()
+		}
+		shard.top.Store(top)
+	}
+}
+
+// NewShardedStore creates a ShardedStore.
+//
+// Parameters:
+//   - shards: The number of stacks, or 0 for GOMAXPROCS.
+//
+// Returns:
+//   - A pointer to the created ShardedStore.
+func NewShardedStore(shards int) *ShardedStore {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	s := &ShardedStore{shards: make([]idleShard, shards)}
+	for i := range s.shards {
+		s.shards[i].list.key = idleSince
+		s.shards[i].list.bySince = true
+	}
+	return s
+}
+
+// Push adds an idle connection on a random shard. It always succeeds.
+func (s *ShardedStore) Push(c IdleConn) bool {
+	shard := &s.shards[rand.IntN(len(s.shards))]
+	shard.mu.Lock()
+	shard.list.insert(c)
+	shard.update()
+	shard.mu.Unlock()
+	return true
+}
+
+// Pop removes the most recently released connection of a random shard or, if that shard is
+// empty, of the shard holding the hottest connection. The shards' tops are compared without
+// locking, so only the shard popped from is locked.
+func (s *ShardedStore) Pop() (IdleConn, bool) {
+	if shard := &s.shards[rand.IntN(len(s.shards))]; shard.n.Load() > 0 {
+		shard.mu.Lock()
+		c, ok := shard.list.popBack()
+		shard.update()
+		shard.mu.Unlock()
+		if ok {
+			return c, true
+		}
+	}
+	for {
+		hottest := -1
+		var top int64
+		for i := range s.shards {
+			shard := &s.shards[i]
+			if shard.n.Load() == 0 {
+				continue
+			}
+			if t := shard.top.Load(); hottest < 0 || t > top {
+				hottest, top = i, t
+			}
+		}
+		if hottest < 0 {
+			return IdleConn{}, false
+		}
+
+		shard := &s.shards[hottest]
+		shard.mu.Lock()
+		c, ok := shard.list.popBack()
+		shard.update()
+		shard.mu.Unlock()
+		if ok {
+			return c, true
+		}
+		// The shard was emptied concurrently; look again.
+	}
+}
+
+// PopColdest removes the least recently released connection across all shards.
+func (s *ShardedStore) PopColdest() (IdleConn, bool) {
+	for {
+		coldest := -1
+		var since time.Time
+		for i := range s.shards {
+			shard := &s.shards[i]
+			if shard.n.Load() == 0 {
+				continue
+			}
+			shard.mu.Lock()
+			if len(shard.list.conns) > 0 && (coldest < 0 || shard.list.conns[0].Since.Before(since)) {
+				coldest, since = i, shard.list.conns[0].Since
+			}
+			shard.mu.Unlock()
+		}
+		if coldest < 0 {
+			return IdleConn{}, false
+		}
+
+		shard := &s.shards[coldest]
+		shard.mu.Lock()
+		c, ok := shard.list.popFront()
+		shard.update()
+		shard.mu.Unlock()
+		if ok {
+			return c, true
+		}
+		// The shard was emptied concurrently; look again.
+	}
+}
+
+// RemoveIf removes the connections matching match from every shard, coldest first.
+func (s *ShardedStore) RemoveIf(match func(c IdleConn) bool) []IdleConn {
+	var removed []IdleConn
+	for i := range s.shards {
+		shard := &s.shards[i]
+		if shard.n.Load() == 0 {
+			continue
+		}
+		shard.mu.Lock()
+		removed = append(removed, shard.list.removeIf(match)...)
+		shard.update()
+		shard.mu.Unlock()
+	}
+	sort.SliceStable(removed, func(i, j int) bool { return removed[i].Since.Before(removed[j].Since) })
+	return removed
+}
+
+// OldestSince returns the earliest Since across all shards.
+func (s *ShardedStore) OldestSince() (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	for i := range s.shards {
+		shard := &s.shards[i]
+		if shard.n.Load() == 0 {
+			continue
+		}
+		shard.mu.Lock()
+		if since, ok := shard.list.oldestSince(); ok && (!found || since.Before(oldest)) {
+			oldest, found = since, true
+		}
+		shard.mu.Unlock()
+	}
+	return oldest, found
+}
+
+// Len returns the number of idle connections.
+func (s *ShardedStore) Len() int {
+	total := 0
+	for i := range s.shards {
+		total += int(s.shards[i].n.Load())
+	}
+	return total
+}
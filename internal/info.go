@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"cmp"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnState says whether a connection is waiting in the pool or checked out.
+type ConnState string
+
+const (
+	ConnIdle  ConnState = "idle"   // The connection is waiting in the pool
+	ConnInUse ConnState = "in_use" // The connection is checked out by a caller
+)
+
+// ConnInfo is a snapshot of the metadata the pool keeps about a connection.
+type ConnInfo struct {
+	ID           uint64            // Identifier of the connection, unique within its pool
+	Network      string            // Network the connection was dialed on
+	Endpoint     string            // Address the connection was dialed to
+	State        ConnState         // Whether the connection is idle or in use
+	Created      time.Time         // When the connection was dialed
+	LastUsed     time.Time         // When the connection was last checked out or released
+	Uses         uint64            // Number of checkouts
+	BytesRead    uint64            // Bytes read through pool handles
+	BytesWritten uint64            // Bytes written through pool handles
+	LastError    error             // The last error seen on the connection, if any
+	Key          string            // Session key the connection was last used with, see GetForKey
+	Tags         map[string]string // Tags set with PooledConn.SetTag
+}
+
+// connInfo is what the pool knows about one of its open connections.
+type connInfo struct {
+	id       uint64
+	endpoint string
+	created  time.Time
+
+	bytesRead    atomic.Uint64
+	bytesWritten atomic.Uint64
+
+	mu       sync.Mutex
+	state    ConnState
+	lastUsed time.Time
+	uses     uint64
+	lastErr  error
+	key      string
+	tags     map[string]string
+}
+
+// track starts keeping metadata about a newly dialed connection.
+//
+// Parameters:
+//   - conn: The raw connection.
+//   - endpoint: The address it was dialed to.
+func (p *ConnectionPool) track(conn net.Conn, endpoint string) {
+	now := time.Now()
+	p.conns.Store(conn, &connInfo{
+		id:       p.nextID.Add(1),
+		endpoint: endpoint,
+		created:  now,
+		state:    ConnInUse,
+		lastUsed: now,
+	})
+}
+
+// info returns the record of an open raw connection.
+//
+// Parameters:
+//   - conn: A raw connection dialed by the pool.
+//
+// Returns:
+//   - The connection's record, or an empty one if the pool does not know conn.
+func (p *ConnectionPool) info(conn net.Conn) *connInfo {
+	if info, ok := p.conns.Load(conn); ok {
+		return info.(*connInfo)
+	}
+	return &connInfo{}
+}
+
+// used records a change of state: a checkout (ConnInUse) or a return to the pool (ConnIdle).
+func (i *connInfo) used(state ConnState) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.state = state
+	i.lastUsed = time.Now()
+	if state == ConnInUse {
+		i.uses++
+	}
+}
+
+// setErr records the last error seen on the connection.
+func (i *connInfo) setErr(err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastErr = err
+}
+
+// snapshot copies the record into a ConnInfo.
+func (i *connInfo) snapshot(network string) ConnInfo {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return ConnInfo{
+		ID:           i.id,
+		Network:      network,
+		Endpoint:     i.endpoint,
+		State:        i.state,
+		Created:      i.created,
+		LastUsed:     i.lastUsed,
+		Uses:         i.uses,
+		BytesRead:    i.bytesRead.Load(),
+		BytesWritten: i.bytesWritten.Load(),
+		LastError:    i.lastErr,
+		Key:          i.key,
+		Tags:         maps.Clone(i.tags),
+	}
+}
+
+// ConnInfo returns the metadata of one of the pool's open connections. It accepts both the handles
+// returned by Get and the raw connections passed to hooks.
+//
+// Parameters:
+//   - conn: The connection.
+//
+// Returns:
+//   - The connection's metadata.
+//   - false if conn is not an open connection of this pool.
+func (p *ConnectionPool) ConnInfo(conn net.Conn) (ConnInfo, bool) {
+	if pc, ok := conn.(*PooledConn); ok {
+		conn = pc.Conn
+	}
+	info, ok := p.conns.Load(conn)
+	if !ok {
+		return ConnInfo{}, false
+	}
+	return info.(*connInfo).snapshot(p.Network), true
+}
+
+// Snapshot lists every open connection of the pool with its metadata, ordered by ID.
+//
+// Returns:
+//   - The connections' metadata.
+func (p *ConnectionPool) Snapshot() []ConnInfo {
+	var infos []ConnInfo
+	p.conns.Range(func(_, value any) bool {
+		infos = append(infos, value.(*connInfo).snapshot(p.Network))
+		return true
+	})
+	slices.SortFunc(infos, func(a, b ConnInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return infos
+}
+
+// Read reads from the connection, counting the bytes and recording any error.
+func (c *PooledConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.info.bytesRead.Add(uint64(n))
+	if err != nil {
+		c.info.setErr(err)
+	}
+	return n, err
+}
+
+// Write writes to the connection, counting the bytes and recording any error.
+func (c *PooledConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.info.bytesWritten.Add(uint64(n))
+	if err != nil {
+		c.info.setErr(err)
+	}
+	return n, err
+}
+
+// Info returns the connection's metadata.
+//
+// Returns:
+//   - A ConnInfo snapshot.
+func (c *PooledConn) Info() ConnInfo {
+	return c.info.snapshot(c.pool.Network)
+}
+
+// SetTag attaches a tag to the connection. Tags stay with the connection across checkouts
+// until it is closed.
+//
+// Parameters:
+//   - key: The tag name.
+//   - value: The tag value.
+func (c *PooledConn) SetTag(key, value string) {
+	c.info.mu.Lock()
+	defer c.info.mu.Unlock()
+	if c.info.tags == nil {
+		c.info.tags = make(map[string]string)
+	}
+	c.info.tags[key] = value
+}
+
+// Tag returns the value of a tag set with SetTag.
+//
+// Parameters:
+//   - key: The tag name.
+//
+// Returns:
+//   - The tag value.
+//   - false if the tag is not set.
+func (c *PooledConn) Tag(key string) (string, bool) {
+	c.info.mu.Lock()
+	defer c.info.mu.Unlock()
+	value, ok := c.info.tags[key]
+	return value, ok
+}
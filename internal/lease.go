@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrConnAlreadyReleased is returned when a connection handle is released more than once.
+	ErrConnAlreadyReleased = errors.New("tcppool: connection already released")
+	// ErrForeignConn is returned when a connection that was not acquired from the pool is released to it.
+	ErrForeignConn = errors.New("tcppool: connection does not belong to this pool")
+)
+
+// PooledConn is the handle given to a caller for a checked-out connection.
+// It behaves like the underlying net.Conn. A new handle is created for every checkout,
+// so a stale handle cannot return the same connection to the pool twice.
+type PooledConn struct {
+	net.Conn
+	pool     *ConnectionPool
+	lease    *lease
+	info     *connInfo
+	released atomic.Bool
+}
+
+// lease records a single checkout of a connection.
+// The pool keeps leases (never handles) so that an abandoned handle can be garbage-collected
+// and picked up by its finalizer.
+type lease struct {
+	conn       net.Conn
+	acquiredAt time.Time
+	req        *request
+	stack      string
+	reported   bool
+}
+
+// LeakInfo describes a connection that was checked out and not released in time.
+type LeakInfo struct {
+	Conn       net.Conn      // The leaked connection
+	AcquiredAt time.Time     // When the connection was checked out
+	Held       time.Duration // How long the connection had been out when the leak was reported
+	Stack      string        // Stack of the acquiring goroutine, if it was sampled
+	Collected  bool          // Whether the handle was garbage-collected without being released
+}
+
+// checkout registers a lease for conn and wraps it in a new handle.
+//
+// Parameters:
+//   - conn: The raw connection being handed to a caller.
+//   - start: When the caller started waiting for the connection.
+//   - req: The caller's request.
+//
+// Returns:
+//   - The handle to give to the caller.
+func (p *ConnectionPool) checkout(conn net.Conn, start time.Time, req *request) *PooledConn {
+	l := &lease{conn: conn, acquiredAt: time.Now(), req: req}
+	p.waits[req.prio.class()].record(l.acquiredAt.Sub(start))
+	if p.LeakStackSampleRate > 0 && rand.Float64() < p.LeakStackSampleRate {
+		buf := make([]byte, 8192)
+		l.stack = string(buf[:runtime.Stack(buf, false)])
+	}
+
+	p.leases.Store(l, struct{}{})
+	inUse := p.inUse.Add(1)
+	if p.scaler != nil {
+		p.scaler.checkedOut(inUse)
+	}
+	p.counters.acquired.Add(1)
+	p.publish(Event{Type: EventConnectionAcquire, Conn: conn, Duration: time.Since(start)})
+
+	info := p.info(conn)
+	info.used(ConnInUse)
+	pc := &PooledConn{Conn: conn, pool: p, lease: l, info: info}
+	if p.LeakThreshold > 0 {
+		runtime.SetFinalizer(pc, func(pc *PooledConn) {
+			if !pc.released.Load() {
+				go p.reclaim(pc.lease)
+			}
+		})
+	}
+	return pc
+}
+
+// checkin ends the lease behind a handle.
+//
+// Parameters:
+//   - conn: The connection being released.
+//
+// Returns:
+//   - The ended lease, holding the raw connection.
+//   - An error, if conn is not a live handle from this pool.
+func (p *ConnectionPool) checkin(conn net.Conn) (*lease, error) {
+	pc, ok := conn.(*PooledConn)
+	if !ok || pc.pool != p {
+		return nil, ErrForeignConn
+	}
+	if !pc.released.CompareAndSwap(false, true) {
+		return nil, ErrConnAlreadyReleased
+	}
+	runtime.SetFinalizer(pc, nil)
+
+	if _, ok := p.leases.LoadAndDelete(pc.lease); ok {
+		p.endLease(pc.lease)
+		if p.scaler != nil {
+			p.scaler.observe(time.Since(pc.lease.acquiredAt))
+		}
+	}
+
+	return pc.lease, nil
+}
+
+// endLease gives back what the caller of an ended lease was counted for.
+//
+// Parameters:
+//   - l: The ended lease.
+func (p *ConnectionPool) endLease(l *lease) {
+	p.inUse.Add(-1)
+	p.unadmit(l.req)
+	p.tenants.leave(l.req.tenant)
+}
+
+// reclaim closes the connection of a lease whose handle was garbage-collected without
+// being released, so the pool gets its capacity back.
+//
+// Parameters:
+//   - l: The abandoned lease.
+func (p *ConnectionPool) reclaim(l *lease) {
+	if _, ok := p.leases.LoadAndDelete(l); !ok {
+		return
+	}
+	p.endLease(l)
+
+	p.reportLeak(LeakInfo{
+		Conn:       l.conn,
+		AcquiredAt: l.acquiredAt,
+		Held:       time.Since(l.acquiredAt),
+		Stack:      l.stack,
+		Collected:  true,
+	})
+
+	p.closeConn(l.conn, "Closing connection reclaimed from a garbage-collected handle")
+}
+
+// minLeakScanInterval bounds how often DetectLeaks scans the leases, however small LeakThreshold is.
+const minLeakScanInterval = time.Millisecond
+
+// DetectLeaks periodically reports connections that have been checked out for longer
+// than LeakThreshold. Each lease is reported at most once. It returns once the pool is closed.
+func (p *ConnectionPool) DetectLeaks() {
+	ticker := time.NewTicker(max(p.LeakThreshold/2, minLeakScanInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+
+		var leaks []LeakInfo
+
+		p.leases.Range(func(key, _ any) bool {
+			l := key.(*lease)
+			held := time.Since(l.acquiredAt)
+			if l.reported || held < p.LeakThreshold {
+				return true
+			}
+			l.reported = true
+			leaks = append(leaks, LeakInfo{
+				Conn:       l.conn,
+				AcquiredAt: l.acquiredAt,
+				Held:       held,
+				Stack:      l.stack,
+			})
+			return true
+		})
+
+		for _, info := range leaks {
+			p.reportLeak(info)
+		}
+	}
+}
+
+// reportLeak forwards a leak to the OnConnectionLeak hook, or logs it.
+//
+// Parameters:
+//   - info: The leak to report.
+func (p *ConnectionPool) reportLeak(info LeakInfo) {
+	p.counters.leaked.Add(1)
+	p.publish(Event{Type: EventConnectionLeak, Conn: info.Conn, Duration: info.Held})
+	if p.Hooks.OnConnectionLeak != nil {
+		p.callHook("OnConnectionLeak", func() { p.Hooks.OnConnectionLeak(info) })
+		return
+	}
+	if info.Collected {
+		fmt.Printf("Connection %v was garbage-collected without being released after %v\n", info.Conn.RemoteAddr(), info.Held)
+	} else {
+		fmt.Printf("Connection %v has been checked out for %v without being released\n", info.Conn.RemoteAddr(), info.Held)
+	}
+	if info.Stack != "" {
+		fmt.Printf("Acquired at:\n%s\n", info.Stack)
+	}
+}
+
+// Owner returns the pool a connection handle was acquired from.
+//
+// Parameters:
+//   - conn: A connection returned by Get.
+//
+// Returns:
+//   - The owning pool, or nil if conn is not a pool handle.
+func Owner(conn net.Conn) *ConnectionPool {
+	pc, ok := conn.(*PooledConn)
+	if !ok {
+		return nil
+	}
+	return pc.pool
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Limiter caps the number of connections open across every pool that uses it.
+// Pools waiting for capacity are served in FIFO order. When the limit is reached, a waiting pool
+// makes room by closing an idle connection of the pool holding the most idle connections.
+type Limiter struct {
+	mu      sync.Mutex
+	max     int
+	used    int
+	waiters list.List // of *limitWaiter, oldest first
+	pools   map[*ConnectionPool]struct{}
+	evicted atomic.Uint64
+}
+
+// limitWaiter is a pool waiting for a connection slot.
+type limitWaiter struct {
+	ready chan struct{} // Closed once a slot has been handed to the waiter
+}
+
+// LimiterStats is a point-in-time snapshot of a Limiter.
+type LimiterStats struct {
+	Max     int    // Maximum number of open connections
+	InUse   int    // Connections currently open across the pools
+	Waiting int    // Dials waiting for a slot
+	Pools   int    // Pools using the limiter
+	Evicted uint64 // Idle connections closed to make room for another pool
+}
+
+// NewLimiter creates a Limiter allowing up to max open connections.
+//
+// Parameters:
+//   - max: The connection limit, greater than zero.
+//
+// Returns:
+//   - A pointer to the created Limiter.
+//   - An error, if max is not positive.
+func NewLimiter(max int) (*Limiter, error) {
+	if max <= 0 {
+		return nil, fmt.Errorf("%w: limiter max must be greater than zero, got %d", ErrInvalidConfig, max)
+	}
+	return &Limiter{max: max, pools: make(map[*ConnectionPool]struct{})}, nil
+}
+
+// SetMax changes the connection limit. Raising it admits waiting dials immediately; lowering it
+// takes effect as connections are closed.
+//
+// Parameters:
+//   - max: The new limit, greater than zero.
+//
+// Returns:
+//   - An error, if max is not positive.
+func (l *Limiter) SetMax(max int) error {
+	if max <= 0 {
+		return fmt.Errorf("%w: limiter max must be greater than zero, got %d", ErrInvalidConfig, max)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = max
+	l.grantLocked()
+	return nil
+}
+
+// Stats returns a snapshot of the limiter.
+//
+// Returns:
+//   - A LimiterStats value.
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LimiterStats{
+		Max:     l.max,
+		InUse:   l.used,
+		Waiting: l.waiters.Len(),
+		Pools:   len(l.pools),
+		Evicted: l.evicted.Load(),
+	}
+}
+
+// waiting reports whether dials are waiting for a slot.
+func (l *Limiter) waiting() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiters.Len() > 0
+}
+
+// register adds a pool to the ones idle connections can be evicted from.
+func (l *Limiter) register(p *ConnectionPool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pools[p] = struct{}{}
+}
+
+// unregister removes a closed pool.
+func (l *Limiter) unregister(p *ConnectionPool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.pools, p)
+}
+
+// acquire takes a slot for a new connection of p, waiting in FIFO order behind other dials.
+// While it waits, it evicts an idle connection from another pool to make room.
+//
+// Parameters:
+//   - ctx: The context bounding the wait.
+//   - p: The pool that is about to dial.
+//
+// Returns:
+//   - An error, if ctx is done before a slot is available.
+func (l *Limiter) acquire(ctx context.Context, p *ConnectionPool) error {
+	l.mu.Lock()
+	if l.used < l.max && l.waiters.Len() == 0 {
+		l.used++
+		l.mu.Unlock()
+		return nil
+	}
+	w := &limitWaiter{ready: make(chan struct{})}
+	elem := l.waiters.PushBack(w)
+	victim := l.victimLocked(p)
+	l.mu.Unlock()
+
+	if victim != nil && victim.evictIdle() {
+		l.evicted.Add(1)
+	}
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// The slot arrived as the wait was abandoned: pass it on.
+			l.used--
+			l.grantLocked()
+		default:
+			l.waiters.Remove(elem)
+		}
+		return ctx.Err()
+	}
+}
+
+// release gives back the slot of a closed connection, or of a dial that failed.
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.used--
+	l.grantLocked()
+}
+
+// grantLocked hands free slots to the oldest waiters. l.mu must be held.
+func (l *Limiter) grantLocked() {
+	for l.used < l.max && l.waiters.Len() > 0 {
+		w := l.waiters.Remove(l.waiters.Front()).(*limitWaiter)
+		l.used++
+		close(w.ready)
+	}
+}
+
+// victimLocked picks the pool to evict an idle connection from: the one with the most idle
+// connections, preferring pools other than p. l.mu must be held.
+//
+// Returns:
+//   - The pool, or nil if no pool has an idle connection.
+func (l *Limiter) victimLocked(p *ConnectionPool) *ConnectionPool {
+	var victim *ConnectionPool
+	most := 0
+	for candidate := range l.pools {
+		n := candidate.idleCount()
+		if candidate == p {
+			// The requesting pool only gives up its own idle connections as a last resort.
+			n = min(n, 1)
+		}
+		if n > most {
+			victim, most = candidate, n
+		}
+	}
+	return victim
+}
+
+// idleCount returns the number of idle connections.
+func (p *ConnectionPool) idleCount() int {
+	return p.idle.Len()
+}
+
+// evictIdle closes the coldest idle connection so that its limiter slot can go to another pool.
+//
+// Returns:
+//   - false if the pool had no idle connection.
+func (p *ConnectionPool) evictIdle() bool {
+	idle, ok := p.idle.PopColdest()
+	if !ok {
+		return false
+	}
+	p.closeConn(idle.Conn, "Closing idle connection to free capacity for another pool")
+	return true
+}
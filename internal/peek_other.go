@@ -0,0 +1,18 @@
+//go:build !unix
+
+package internal
+
+import "net"
+
+// peekPending reports how many bytes can be read from conn without consuming them.
+// Non-blocking peeks are only implemented on unix, so other platforms report none.
+//
+// Parameters:
+//   - conn: The connection to inspect.
+//   - limit: The most bytes to count.
+//
+// Returns:
+//   - Always 0 and nil.
+func peekPending(conn net.Conn, limit int) (int, error) {
+	return 0, nil
+}
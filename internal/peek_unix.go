@@ -0,0 +1,69 @@
+//go:build unix
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// errPeerClosed is returned by peekPending when the peer has closed the connection.
+var errPeerClosed = fmt.Errorf("peer closed the connection: %w", io.EOF)
+
+// wrapper is implemented by connections layered over another one, such as *tls.Conn.
+type wrapper interface {
+	NetConn() net.Conn
+}
+
+// peekPending reports how many bytes can be read from conn right now, without blocking
+// and without consuming them. Wrapped connections are inspected through their underlying socket,
+// so a TLS connection reports the encrypted bytes pending, but not the data the TLS layer already
+// read from the socket. Connections that do not expose a file descriptor report none.
+//
+// Parameters:
+//   - conn: The connection to inspect.
+//   - limit: The most bytes to count, e.g. 1 to only learn whether any are pending.
+//
+// Returns:
+//   - The number of readable bytes, up to limit.
+//   - An error, if the peer closed the connection or the socket failed.
+func peekPending(conn net.Conn, limit int) (int, error) {
+	for {
+		w, ok := conn.(wrapper)
+		if !ok {
+			break
+		}
+		conn = w.NetConn()
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return 0, nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	var peekErr error
+	buf := make([]byte, limit)
+	err = rc.Read(func(fd uintptr) bool {
+		n, _, peekErr = syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case peekErr == syscall.EAGAIN || peekErr == syscall.EWOULDBLOCK:
+		return 0, nil
+	case peekErr != nil:
+		return 0, peekErr
+	case n == 0:
+		return 0, errPeerClosed
+	}
+	return n, nil
+}
@@ -1,9 +1,13 @@
 package internal
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/meliadamian17/tcppool/internal/backoff"
@@ -11,17 +15,65 @@ import (
 
 // ConnectionPool represents a pool of reusable TCP connections.
 // It manages the creation, reuse, and cleanup of idle connections.
+// The tunable fields can be changed with Reconfigure while the pool is in use; the pool itself reads
+// them through settings, so that Get and Release never need p.mu while idle connections are available.
 type ConnectionPool struct {
+	Network        string          // Network used to dial, "tcp" by default
 	Address        string          // Network address for the pool's connections
 	Name           string          // Name of the connection pool
-	MaxConnections int             // Maximum number of active connections
+	MaxConnections int             // Maximum number of open connections
 	IdleTimeout    time.Duration   // Duration after which idle connections are cleaned up
 	ConnTimeout    time.Duration   // Timeout for establishing a new connection
-	IdleConns      chan net.Conn   // Channel for storing idle connections
-	ActiveConns    int             // Current number of active connections
+	ActiveConns    int             // Current number of open connections, idle or in use
 	MaxRetries     uint            // Maximum number of retries for connection establishment
 	Backoff        backoff.Backoff // Backoff strategy for retries
 	Hooks          PoolHooks       // Hooks for connection pool events
+
+	LeakThreshold       time.Duration // Checkout duration after which a connection is reported as leaked (0 disables)
+	LeakStackSampleRate float64       // Fraction of checkouts that record the acquiring goroutine's stack
+	DoRetries           uint          // Number of times Do retries a retryable failure on a fresh connection
+	CheckDirtyOnRelease bool          // Whether Release discards connections with unread data pending
+	RepanicHooks        bool          // Whether hook panics are re-raised after being reported
+	IdleOrder           IdleOrder     // Which idle connection is reused first and which is retired first
+	ValidateConcurrency int           // Idle connections validated at once (DefaultValidateConcurrency if 0)
+	ReservedConnections int           // Connections only callers with PriorityHigh may use
+
+	dialer    DialFunc    // Custom dial function, or nil for net.Dialer
+	tlsConfig *tls.Config // TLS client configuration, or nil for plain TCP
+	limiter   *Limiter    // Limit shared with other pools, or nil
+
+	idle       IdleStore                   // Connections waiting to be reused
+	conns      sync.Map                    // Open raw connections, net.Conn -> *connInfo
+	nextID     atomic.Uint64               // Last connection ID handed out
+	cfg        atomic.Pointer[settings]    // Snapshot of the tunable fields
+	leases     sync.Map                    // Outstanding checkouts, *lease -> struct{}
+	inUse      atomic.Int64                // Number of outstanding checkouts
+	waiters    atomic.Int32                // Callers waiting for an idle connection or capacity; changed with p.mu held
+	overLimit  atomic.Bool                 // Whether more connections are open than MaxConnections, after a Resize
+	closed     atomic.Bool                 // Whether Close has been called; set with p.mu held
+	validating atomic.Bool                 // Whether idle connections are being validated
+	requeued   chan struct{}               // Signaled when validation puts connections back, to re-arm expiry
+	rechecked  chan struct{}               // Signaled when Reconfigure changes the health check, to re-arm it
+	queued     [numPriorities]atomic.Int32 // Waiting callers, by priority class
+	shared     atomic.Int64                // Connections held or being acquired by callers below PriorityHigh
+	held       atomic.Int64                // Connections held or being acquired by any caller
+	tenants    tenants                     // Tenant quotas and accounting
+	waits      [numPriorities]waitCounters // Acquisition wait times, by priority class
+	scaler     *autoscaler                 // Adjusts MaxConnections, or nil
+
+	endpoints    []*endpoint          // Address, then the other Endpoints
+	byAddr       map[string]*endpoint // The endpoints by address
+	nextEndpoint atomic.Uint64        // Round-robin position among the endpoints
+	outliers     OutlierConfig        // Outlier detection settings, with defaults applied, if enabled
+	healthCheck  *HealthCheckConfig   // Health check settings, with defaults applied, or nil; read them from settings
+
+	mu        sync.Mutex
+	dialing   int                      // Dials in progress, counted against MaxConnections
+	wake      chan struct{}            // Closed to wake the waiters
+	done      chan struct{}            // Closed by Close to stop the background goroutines
+	discarded map[DiscardReason]uint64 // Discard counts by reason
+	counters  counters
+	events    eventBus
 }
 
 // NewConnectionPool initializes a new ConnectionPool with the given configuration.
@@ -33,37 +85,90 @@ type ConnectionPool struct {
 //   - A pointer to the created ConnectionPool.
 //   - An error, if the initialization fails.
 func NewConnectionPool(c ConfigImpl) (*ConnectionPool, error) {
-	if c.MaxConnections < 0 {
-		err := errors.New(
-			fmt.Sprintf("Max Conns must be greater than 0. Supplied: %v", c.MaxConnections),
-		)
+	if err := c.Validate(); err != nil {
 		if c.Hooks.OnPoolCreateError != nil {
-			c.Hooks.OnPoolCreateError(err)
+			guardHook(&c.Hooks, c.RepanicHooks, "OnPoolCreateError", func() { c.Hooks.OnPoolCreateError(err) })
 		} else {
 			fmt.Println(err)
 		}
 		return nil, err
 	}
 
+	if c.Network == "" {
+		c.Network = "tcp"
+	}
+	if c.IdleOrder == "" {
+		c.IdleOrder = IdleLIFO
+	}
+
 	pool := &ConnectionPool{
+		Network:        c.Network,
 		Address:        c.Address,
 		Name:           c.Name,
 		MaxConnections: c.MaxConnections,
 		ConnTimeout:    c.ConnTimeout,
 		IdleTimeout:    c.IdleTimeout,
-		IdleConns:      make(chan net.Conn, c.MaxConnections),
 		MaxRetries:     c.MaxRetries,
 		Backoff:        c.Backoff,
 		Hooks:          c.Hooks,
+
+		LeakThreshold:       c.LeakThreshold,
+		LeakStackSampleRate: c.LeakStackSampleRate,
+		DoRetries:           c.DoRetries,
+		CheckDirtyOnRelease: c.CheckDirtyOnRelease,
+		RepanicHooks:        c.RepanicHooks,
+		IdleOrder:           c.IdleOrder,
+		ValidateConcurrency: c.ValidateConcurrency,
+		ReservedConnections: c.ReservedConnections,
+
+		dialer:    c.Dialer,
+		tlsConfig: c.TLSConfig,
+		limiter:   c.Limiter,
+
+		idle:      NewIdleStore(c.IdleOrder),
+		wake:      make(chan struct{}),
+		done:      make(chan struct{}),
+		requeued:  make(chan struct{}, 1),
+		rechecked: make(chan struct{}, 1),
+		discarded: make(map[DiscardReason]uint64),
+	}
+	if c.HealthCheck != nil {
+		h := c.HealthCheck.withDefaults()
+		pool.healthCheck = &h
+	}
+	pool.storeSettings()
+	pool.tenants.set(c.TenantQuotas, c.DefaultTenantQuota)
+	if c.Autoscale != nil {
+		pool.scaler = newAutoscaler(*c.Autoscale)
+	}
+	pool.endpoints, pool.byAddr = newEndpoints(c)
+	if c.OutlierDetection != nil {
+		pool.outliers = c.OutlierDetection.withDefaults()
 	}
 
 	if pool.Hooks.OnPoolCreate != nil {
-		pool.Hooks.OnPoolCreate(c)
+		pool.callHook("OnPoolCreate", func() { pool.Hooks.OnPoolCreate(c) })
 	} else {
 		fmt.Printf("New connection pool for address %v created\n", pool.Address)
 	}
 
+	if pool.limiter != nil {
+		pool.limiter.register(pool)
+	}
+
 	go pool.CleanupIdleConns()
+	if pool.LeakThreshold > 0 {
+		go pool.DetectLeaks()
+	}
+	if pool.scaler != nil {
+		go pool.Autoscale()
+	}
+	if c.OutlierDetection != nil {
+		go pool.DetectOutliers()
+	}
+	if pool.healthCheck != nil {
+		go pool.CheckHealth()
+	}
 
 	return pool, nil
 }
@@ -75,114 +180,458 @@ func NewConnectionPool(c ConfigImpl) (*ConnectionPool, error) {
 //   - A net.Conn object representing the connection.
 //   - An error, if the connection retrieval fails.
 func (p *ConnectionPool) Get() (net.Conn, error) {
-	select {
-	case conn := <-p.IdleConns:
-		if Validate(conn) {
-			if p.Hooks.OnConnectionAcquire != nil {
-				p.Hooks.OnConnectionAcquire(conn)
-			} else {
-				fmt.Printf("Idle connection found to %v\n", p.Address)
-			}
+	return p.GetContext(context.Background())
+}
+
+// GetContext retrieves a connection from the pool like Get, but gives up as soon as
+// ctx is done, including while dialing or waiting out a backoff delay between retries.
+// The returned connection is a *PooledConn handle.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//
+// Returns:
+//   - A net.Conn object representing the connection.
+//   - An error, if the connection retrieval fails or ctx is done first.
+func (p *ConnectionPool) GetContext(ctx context.Context) (net.Conn, error) {
+	start := time.Now()
+	req := newRequest(ctx, false, "")
+	conn, err := p.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.checkout(conn, start, req), nil
+}
+
+// acquire takes an idle connection or dials a new one, and runs the OnBorrow hook on it.
+// A connection rejected by OnBorrow is discarded; an idle one is replaced by the next candidate,
+// while the rejection of a freshly dialed one is returned to the caller.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - req: What the caller asks for.
+//
+// Returns:
+//   - The raw connection, counted against the caller as takeOrDial describes.
+//   - An error, if the connection retrieval fails or ctx is done first.
+func (p *ConnectionPool) acquire(ctx context.Context, req *request) (conn net.Conn, err error) {
+	if err := p.tenants.enter(req.tenant); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			p.tenants.leave(req.tenant)
+		} else {
+			p.tenants.acquired(req.tenant)
+		}
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		conn, dialed, err := p.takeOrDial(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if p.Hooks.OnBorrow == nil {
 			return conn, nil
 		}
-		fmt.Printf("No valid idle connection found! Trying to open a new connection...\n")
-		return p.newConnection()
-	default:
-		fmt.Printf("No idle connection found! Trying to open a new connection...\n")
-		return p.newConnection()
+		err = p.callHookErr("OnBorrow", func() error { return p.Hooks.OnBorrow(ctx, conn) })
+		if err != nil {
+			p.unadmit(req)
+			p.discardRaw(conn, rejectReason(err), err)
+			if dialed {
+				return nil, fmt.Errorf("connection rejected on borrow: %w", err)
+			}
+			continue
+		}
+		return conn, nil
+	}
+}
+
+// takeOrDial takes a valid idle connection if there is one, or dials a new one if the pool
+// is under MaxConnections. Otherwise it waits until a connection is released or capacity frees up.
+// With a Limiter, a new connection also waits for a slot of the shared limit.
+// Callers yield to waiters of a higher priority, and callers below PriorityHigh also wait while
+// they hold MaxConnections-ReservedConnections connections between them.
+//
+// Parameters:
+//   - ctx: The context bounding the wait and the dial attempts.
+//   - req: What the caller asks for.
+//
+// Returns:
+//   - The raw connection, counted against the caller by admit.
+//   - Whether the connection was freshly dialed.
+//   - An error, if the connection creation fails or ctx is done first.
+func (p *ConnectionPool) takeOrDial(ctx context.Context, req *request) (net.Conn, bool, error) {
+	fresh, key, prio := req.fresh, req.key, req.prio
+	queued := false
+	defer func() {
+		if queued {
+			p.dequeue(prio)
+		}
+	}()
+
+	for {
+		if p.closed.Load() {
+			return nil, false, ErrPoolClosed
+		}
+
+		admitted := p.admit(req)
+		if admitted && !fresh {
+			if idle, ok := p.popIdle(key); ok {
+				if conn, ok := p.useIdle(idle.Conn); ok {
+					return conn, false, nil
+				}
+				p.unadmit(req)
+				fmt.Printf("No valid idle connection found! Trying the next one...\n")
+				continue
+			}
+			fmt.Printf("No idle connection found! Trying to open a new connection...\n")
+		}
+
+		p.mu.Lock()
+		if admitted && p.ActiveConns+p.dialing < p.MaxConnections {
+			p.dialing++
+			p.mu.Unlock()
+			if p.limiter != nil {
+				if err := p.limiter.acquire(ctx, p); err != nil {
+					p.mu.Lock()
+					p.dialing--
+					p.notifyLocked()
+					p.mu.Unlock()
+					p.unadmit(req)
+					return nil, false, err
+				}
+			}
+			conn, err := p.newConnection(ctx)
+			if err != nil {
+				p.unadmit(req)
+			}
+			return conn, true, err
+		}
+		if admitted && fresh {
+			if idle, ok := p.idle.PopColdest(); ok {
+				// A fresh connection is required but the pool is full: make room by retiring an idle one.
+				p.mu.Unlock()
+				p.unadmit(req)
+				p.closeConn(idle.Conn, "Closing idle connection to make room for a new one")
+				continue
+			}
+		}
+		if admitted {
+			p.unadmit(req)
+		}
+
+		p.waiters.Add(1)
+		if !queued {
+			p.queued[prio.class()].Add(1)
+			queued = true
+		}
+		wake := p.wake
+		p.mu.Unlock()
+
+		// A connection released before the waiter was registered did not wake anyone.
+		if !fresh && p.admit(req) {
+			if idle, ok := p.popIdle(key); ok {
+				p.waiters.Add(-1)
+				if conn, ok := p.useIdle(idle.Conn); ok {
+					return conn, false, nil
+				}
+				p.unadmit(req)
+				continue
+			}
+			p.unadmit(req)
+		}
+
+		waitStart := p.startQueuing()
+		select {
+		case <-wake:
+			p.waiters.Add(-1)
+			p.stopQueuing(waitStart)
+		case <-ctx.Done():
+			p.waiters.Add(-1)
+			p.stopQueuing(waitStart)
+			return nil, false, ctx.Err()
+		}
 	}
 }
 
-// newConnectionAsync creates a new connection asynchronously and applies backoff strategies for retries.
+// useIdle checks, without blocking, that an idle connection is still alive before it is handed out
+// again. Dead connections are closed.
+//
+// Parameters:
+//   - conn: The connection taken from the idle list.
 //
 // Returns:
-//   - A channel that sends a struct containing the connection or an error.
-func (p *ConnectionPool) newConnectionAsync() <-chan struct {
-	conn net.Conn
-	err  error
-} {
-	resultChan := make(chan struct {
-		conn net.Conn
-		err  error
-	})
-
-	go func() {
+//   - The connection.
+//   - false if the connection cannot be used.
+func (p *ConnectionPool) useIdle(conn net.Conn) (net.Conn, bool) {
+	if !alive(conn) || conn.SetDeadline(time.Time{}) != nil {
+		if e := p.endpointOf(conn); e != nil {
+			e.record(true)
+		}
+		p.closeConn(conn, "Closing invalid idle connection")
+		return nil, false
+	}
+	if p.Hooks.OnConnectionAcquire == nil {
+		fmt.Printf("Idle connection found to %v\n", p.Address)
+		return conn, true
+	}
+	if err := p.callHook("OnConnectionAcquire", func() { p.Hooks.OnConnectionAcquire(conn) }); err != nil {
+		p.discardRaw(conn, DiscardPanic, err)
+		return nil, false
+	}
+	return conn, true
+}
+
+// ErrDialFailed is matched by the error returned when every dial attempt for a new connection fails.
+var ErrDialFailed = errors.New("tcppool: failed to establish connection")
+
+// dial creates a new connection and applies the backoff strategy between retries.
+// A connection rejected by the OnConnect hook is closed and counts as a failed attempt.
+// Each attempt goes to the next healthy endpoint that is not ejected. It stops early once ctx is done,
+// and fails right away if no endpoint is healthy.
+//
+// Parameters:
+//   - ctx: The context bounding the dial attempts.
+//
+// Returns:
+//   - A net.Conn object representing the connection.
+//   - The address of the endpoint it was dialed to.
+//   - The number of attempts made.
+//   - An error matching ErrDialFailed if every attempt fails, or ctx's error if it is done first.
+func (p *ConnectionPool) dial(ctx context.Context) (net.Conn, string, uint, error) {
+	start := time.Now()
+	cfg := p.settings()
+
+	var err error
+	var addr string
+	for attempt := 1; attempt <= int(cfg.maxRetries); attempt++ {
+		e := p.pickEndpoint()
+		if e == nil {
+			return nil, addr, uint(attempt - 1), ErrNoHealthyEndpoint
+		}
+		addr = e.addr
 		var conn net.Conn
-		var err error
-
-		for attempt := 1; attempt <= int(p.MaxRetries); attempt++ {
-			conn, err = net.DialTimeout("tcp", p.Address, p.ConnTimeout)
-			if err == nil {
-				resultChan <- struct {
-					conn net.Conn
-					err  error
-				}{conn: conn, err: nil}
-				close(resultChan)
-				return
+		conn, err = p.dialOnce(ctx, cfg.connTimeout, addr)
+		if err == nil && p.Hooks.OnConnect != nil {
+			err = p.callHookErr("OnConnect", func() error { return p.Hooks.OnConnect(ctx, conn) })
+			if err != nil {
+				conn.Close()
+				err = fmt.Errorf("connection setup failed: %w", err)
 			}
+		}
+		if err == nil {
+			e.record(false)
+			p.publishDial(Event{Type: EventDialAttempt, Address: addr, Conn: conn, Attempt: uint(attempt), Duration: time.Since(start)})
+			return conn, addr, uint(attempt), nil
+		}
+		p.publishDial(Event{Type: EventDialAttempt, Address: addr, Attempt: uint(attempt), Duration: time.Since(start), Err: err})
 
-			time.Sleep(p.Backoff.NextRetry(uint(attempt)))
+		if ctx.Err() != nil {
+			return nil, addr, uint(attempt), ctx.Err()
+		}
+		e.record(true)
+		if attempt == int(cfg.maxRetries) {
+			break
 		}
 
-		resultChan <- struct {
-			conn net.Conn
-			err  error
-		}{conn: nil, err: fmt.Errorf("failed to establish connection after %d retries: %w", p.MaxRetries, err)}
-		close(resultChan)
-	}()
+		delay := cfg.backoff.NextRetry(uint(attempt))
+		p.publishDial(Event{Type: EventDialRetry, Address: addr, Attempt: uint(attempt), Duration: time.Since(start), Delay: delay, Err: err})
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, addr, uint(attempt), ctx.Err()
+		}
+	}
 
-	return resultChan
+	return nil, addr, cfg.maxRetries, fmt.Errorf("%w after %d retries: %w", ErrDialFailed, cfg.maxRetries, err)
+}
+
+// dialOnce makes a single dial attempt, bounded by timeout, using the configured dialer
+// and performing the TLS handshake when TLS is enabled.
+//
+// Parameters:
+//   - ctx: The context bounding the attempt.
+//   - timeout: The ConnTimeout in effect, or 0 for none.
+//   - addr: The endpoint to dial.
+//
+// Returns:
+//   - A net.Conn object representing the connection.
+//   - An error, if the attempt fails.
+func (p *ConnectionPool) dialOnce(ctx context.Context, timeout time.Duration, addr string) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var conn net.Conn
+	var err error
+	if p.dialer != nil {
+		conn, err = p.dialer(ctx, p.Network, addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, p.Network, addr)
+	}
+	if err != nil || p.tlsConfig == nil {
+		return conn, err
+	}
+
+	cfg := p.tlsConfig
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		if host, _, splitErr := net.SplitHostPort(addr); splitErr == nil {
+			cfg.ServerName = host
+		} else {
+			cfg.ServerName = addr
+		}
+	}
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
 }
 
 // newConnection creates a new connection synchronously and triggers hooks for connection events.
+// The caller must have reserved the capacity for it by incrementing p.dialing, and taken a limiter slot.
+//
+// Parameters:
+//   - ctx: The context bounding the dial attempts.
 //
 // Returns:
 //   - A net.Conn object representing the connection.
 //   - An error, if the connection creation fails.
-func (p *ConnectionPool) newConnection() (net.Conn, error) {
-	resultChan := p.newConnectionAsync()
-	result := <-resultChan
-	if result.err != nil {
+func (p *ConnectionPool) newConnection(ctx context.Context) (net.Conn, error) {
+	start := time.Now()
+	conn, addr, attempts, err := p.dial(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.dialing--
+		p.notifyLocked()
+		p.mu.Unlock()
+		if p.limiter != nil {
+			p.limiter.release()
+		}
+		p.counters.dialErrors.Add(1)
+		p.publish(Event{Type: EventConnectionError, Address: addr, Duration: time.Since(start), Attempt: attempts, Err: err})
 		if p.Hooks.OnConnectionError != nil {
-			p.Hooks.OnConnectionError(result.err)
+			p.callHook("OnConnectionError", func() { p.Hooks.OnConnectionError(err) })
 		} else {
-			fmt.Printf("Failed to create new connection: %v\n", result.err)
+			fmt.Printf("Failed to create new connection: %v\n", err)
 		}
-		return nil, result.err
+		return nil, err
 	}
 
+	p.track(conn, addr)
+	p.mu.Lock()
+	p.dialing--
+	p.ActiveConns++
+	p.mu.Unlock()
+	p.counters.created.Add(1)
+	p.publish(Event{Type: EventConnectionCreate, Conn: conn, Duration: time.Since(start), Attempt: attempts})
+
 	if p.Hooks.OnConnectionCreate != nil {
-		p.Hooks.OnConnectionCreate(result.conn)
+		if err := p.callHook("OnConnectionCreate", func() { p.Hooks.OnConnectionCreate(conn) }); err != nil {
+			p.discardRaw(conn, DiscardPanic, err)
+			return nil, err
+		}
 	} else {
-		fmt.Printf("New connection created: %v\n", result.conn)
+		fmt.Printf("New connection created: %v\n", conn)
 	}
 
-	return result.conn, nil
+	return conn, nil
 }
 
-// Release returns a previously acquired connection to the pool.
-// If the pool is full, the connection is closed instead.
+// Release returns a previously acquired connection to the pool and wakes a waiting caller.
+// The connection is closed instead if the pool holds more connections than MaxConnections (after a Resize),
+// or if another pool sharing its Limiter is waiting for capacity while nobody waits on this pool.
+// Deadlines left by the caller are cleared, and with CheckDirtyOnRelease a connection with unread data
+// pending is discarded.
 //
 // Parameters:
-//   - conn: The connection to be returned to the pool.
+//   - conn: The connection to be returned to the pool, as returned by Get.
 //
 // Returns:
-//   - An error, if the release process fails.
+//   - An error, if the release process fails or conn was not acquired from this pool.
 func (p *ConnectionPool) Release(conn net.Conn) error {
-	select {
-	case p.IdleConns <- conn:
-		if p.Hooks.OnConnectionRelease != nil {
-			p.Hooks.OnConnectionRelease(conn)
-		} else {
-			fmt.Println("Successfully released connection back into the pool")
+	return p.release(conn, nil)
+}
+
+// release returns a connection to the pool like Release, recording cause against its endpoint.
+//
+// Parameters:
+//   - conn: The connection handle to be returned to the pool.
+//   - cause: The error the caller observed on the connection without breaking it, or nil.
+//
+// Returns:
+//   - An error, if the release process fails or conn was not acquired from this pool.
+func (p *ConnectionPool) release(conn net.Conn, cause error) error {
+	l, err := p.checkin(conn)
+	if err != nil {
+		return err
+	}
+	conn = l.conn
+	p.settle(l, cause)
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return p.discardRaw(conn, DiscardError, err)
+	}
+	if p.settings().checkDirty {
+		if err := p.checkDirty(l); err != nil {
+			reason := DiscardDirty
+			if !errors.Is(err, ErrDirtyConn) {
+				reason = DiscardError
+			}
+			return p.discardRaw(conn, reason, err)
 		}
-		return nil
-	default:
-		if p.Hooks.OnConnectionClose != nil {
-			p.Hooks.OnConnectionClose(conn)
-		} else {
-			fmt.Println("Connection is closing due to pool being full")
+	}
+	if p.Hooks.OnReturn != nil {
+		if err := p.callHookErr("OnReturn", func() error { return p.Hooks.OnReturn(conn) }); err != nil {
+			return p.discardRaw(conn, rejectReason(err), err)
+		}
+	}
+
+	if p.limiter != nil && p.limiter.waiting() && p.waiters.Load() == 0 {
+		p.limiter.evicted.Add(1)
+		return p.closeConn(conn, "Connection is closing to free capacity for another pool")
+	}
+	if e := p.endpointOf(conn); e != nil && !e.usable() {
+		return p.closeConn(conn, "Connection is closing because its endpoint is ejected or unhealthy")
+	}
+	if p.overLimit.Load() {
+		p.mu.Lock()
+		over := p.ActiveConns > p.MaxConnections
+		p.mu.Unlock()
+		if over {
+			return p.closeConn(conn, "Connection is closing due to pool being full")
 		}
-		return conn.Close()
 	}
+	info := p.info(conn)
+	info.used(ConnIdle)
+	if !p.idle.Push(IdleConn{Conn: conn, Since: time.Now(), Created: info.created, Key: info.sessionKey()}) {
+		return p.closeConn(conn, "Connection is closing due to pool being full")
+	}
+	if p.closed.Load() {
+		// Close may have drained the store before conn was pushed.
+		p.drainIdle("Closing idle connection of a closed pool")
+		return nil
+	}
+	p.wakeWaiters()
+
+	p.counters.released.Add(1)
+	p.publish(Event{Type: EventConnectionRelease, Conn: conn, Duration: time.Since(l.acquiredAt)})
+	if p.Hooks.OnConnectionRelease != nil {
+		p.callHook("OnConnectionRelease", func() { p.Hooks.OnConnectionRelease(conn) })
+	} else {
+		fmt.Println("Successfully released connection back into the pool")
+	}
+	return nil
 }
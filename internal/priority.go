@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Priority orders callers competing for connections of a saturated pool.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1 // Background work, such as batch jobs
+	PriorityNormal Priority = 0  // The default
+	PriorityHigh   Priority = 1  // Latency-sensitive work, such as user-facing requests; may use reserved connections
+)
+
+// numPriorities is the number of priority classes.
+const numPriorities = 3
+
+// class returns the index of the priority's class, clamping unknown priorities to the nearest class.
+func (pr Priority) class() int {
+	return int(min(max(pr, PriorityLow), PriorityHigh) - PriorityLow)
+}
+
+// String returns "low", "normal" or "high".
+func (pr Priority) String() string {
+	return [numPriorities]string{"low", "normal", "high"}[pr.class()]
+}
+
+// priorityKey is the context key of the caller's priority.
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of ctx carrying prio, for GetContext and the other
+// context-taking acquisition methods.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - prio: The priority of the acquisitions made with the returned context.
+//
+// Returns:
+//   - The derived context.
+func ContextWithPriority(ctx context.Context, prio Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, prio)
+}
+
+// PriorityFrom returns the priority carried by ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The priority set with ContextWithPriority, or PriorityNormal.
+func PriorityFrom(ctx context.Context) Priority {
+	if prio, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return Priority(min(max(prio, PriorityLow), PriorityHigh))
+	}
+	return PriorityNormal
+}
+
+// WaitStats reports how long the callers of a priority class waited for their connections.
+type WaitStats struct {
+	Waiting   int           // Callers of the class currently waiting
+	Acquired  uint64        // Successful acquisitions
+	TotalWait time.Duration // Time spent acquiring, summed over the acquisitions
+	MaxWait   time.Duration // Longest acquisition
+}
+
+// request describes what a caller asks the pool for.
+type request struct {
+	fresh  bool     // Whether a newly dialed connection is required
+	key    string   // Session key whose idle connections are preferred, or empty
+	prio   Priority // The caller's priority
+	tenant string   // The caller's tenant, or empty
+}
+
+// newRequest describes an acquisition made with ctx.
+//
+// Parameters:
+//   - ctx: The caller's context, carrying its priority and tenant.
+//   - fresh: Whether a newly dialed connection is required.
+//   - key: The session key whose idle connections are preferred, or empty.
+//
+// Returns:
+//   - The request.
+func newRequest(ctx context.Context, fresh bool, key string) *request {
+	return &request{fresh: fresh, key: key, prio: PriorityFrom(ctx), tenant: TenantFrom(ctx)}
+}
+
+// waitCounters holds the counters behind WaitStats.
+type waitCounters struct {
+	acquired  atomic.Uint64
+	totalWait atomic.Int64
+	maxWait   atomic.Int64
+}
+
+// record accounts for an acquisition that took wait.
+func (w *waitCounters) record(wait time.Duration) {
+	w.acquired.Add(1)
+	w.totalWait.Add(int64(wait))
+	for {
+		longest := w.maxWait.Load()
+		if int64(wait) <= longest || w.maxWait.CompareAndSwap(longest, int64(wait)) {
+			return
+		}
+	}
+}
+
+// admit lets a caller compete for an idle connection or a dial. It fails while callers of a
+// higher priority are waiting, below PriorityHigh while the callers below PriorityHigh already
+// hold MaxConnections-ReservedConnections connections, and when the connection is needed for the
+// guaranteed minimum of another tenant. Otherwise a connection is counted against the caller
+// until unadmit or the end of the lease.
+//
+// Parameters:
+//   - req: The caller's request.
+//
+// Returns:
+//   - Whether the caller may take a connection.
+func (p *ConnectionPool) admit(req *request) bool {
+	prio := req.prio
+	for c := prio.class() + 1; c < numPriorities; c++ {
+		if p.queued[c].Load() > 0 {
+			return false
+		}
+	}
+	cfg := p.settings()
+	if prio != PriorityHigh && !p.share(int64(cfg.maxConnections-cfg.reserved)) {
+		return false
+	}
+	if !p.tenants.admit(req.tenant, &p.held, cfg.maxConnections) {
+		if prio != PriorityHigh {
+			p.shared.Add(-1)
+		}
+		return false
+	}
+	return true
+}
+
+// share counts a connection against the callers below PriorityHigh, if they hold fewer than limit.
+//
+// Parameters:
+//   - limit: The connections the callers below PriorityHigh may hold.
+//
+// Returns:
+//   - Whether the connection was counted.
+func (p *ConnectionPool) share(limit int64) bool {
+	for {
+		n := p.shared.Load()
+		if n >= limit {
+			return false
+		}
+		if p.shared.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// unadmit gives back what admit counted when no connection is handed to the caller after all.
+//
+// Parameters:
+//   - req: The caller's request.
+func (p *ConnectionPool) unadmit(req *request) {
+	if req.prio != PriorityHigh {
+		p.shared.Add(-1)
+	}
+	p.tenants.unadmit(req.tenant, &p.held)
+}
+
+// dequeue removes a caller from the waiters of its priority class, and wakes the lower classes
+// that were yielding to it.
+//
+// Parameters:
+//   - prio: The caller's priority.
+func (p *ConnectionPool) dequeue(prio Priority) {
+	p.queued[prio.class()].Add(-1)
+	for c := prio.class() - 1; c >= 0; c-- {
+		if p.queued[c].Load() > 0 {
+			p.mu.Lock()
+			p.notifyLocked()
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// waitStats returns the wait statistics of every priority class.
+//
+// Returns:
+//   - The statistics, keyed by priority.
+func (p *ConnectionPool) waitStats() map[Priority]WaitStats {
+	stats := make(map[Priority]WaitStats, numPriorities)
+	for c := range numPriorities {
+		w := &p.waits[c]
+		stats[Priority(c)+PriorityLow] = WaitStats{
+			Waiting:   int(p.queued[c].Load()),
+			Acquired:  w.acquired.Load(),
+			TotalWait: time.Duration(w.totalWait.Load()),
+			MaxWait:   time.Duration(w.maxWait.Load()),
+		}
+	}
+	return stats
+}
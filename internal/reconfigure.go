@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal/backoff"
+)
+
+// settings are the tunables Reconfigure can change while the pool is in use.
+type settings struct {
+	maxConnections int
+	connTimeout    time.Duration
+	idleTimeout    time.Duration
+	maxRetries     uint
+	backoff        backoff.Backoff
+	doRetries      uint
+	checkDirty     bool
+	reserved       int
+	healthCheck    *HealthCheckConfig // With defaults applied, or nil
+}
+
+// settings returns a consistent copy of the pool's tunables, without locking.
+//
+// Returns:
+//   - The current settings.
+func (p *ConnectionPool) settings() settings {
+	return *p.cfg.Load()
+}
+
+// storeSettings publishes the tunable fields to settings. p.mu must be held, unless the pool
+// is not shared yet.
+func (p *ConnectionPool) storeSettings() {
+	p.cfg.Store(&settings{
+		maxConnections: p.MaxConnections,
+		connTimeout:    p.ConnTimeout,
+		idleTimeout:    p.IdleTimeout,
+		maxRetries:     p.MaxRetries,
+		backoff:        p.Backoff,
+		doRetries:      p.DoRetries,
+		checkDirty:     p.CheckDirtyOnRelease,
+		reserved:       p.ReservedConnections,
+		healthCheck:    p.healthCheck,
+	})
+}
+
+// Resize changes the maximum number of open connections.
+// Growing the pool immediately admits callers waiting for capacity. Shrinking it closes surplus
+// idle connections right away; checked-out connections above the new limit are closed when released,
+// and no new connections are dialed until the pool is back under the limit.
+//
+// Parameters:
+//   - maxConnections: The new maximum, greater than zero.
+//
+// Returns:
+//   - An error, if maxConnections is not positive, not above ReservedConnections, or too small for
+//     the tenants' guaranteed minimums.
+func (p *ConnectionPool) Resize(maxConnections int) error {
+	if maxConnections <= 0 {
+		return fmt.Errorf("%w: max connections must be greater than zero, got %d", ErrInvalidConfig, maxConnections)
+	}
+
+	var surplus []net.Conn
+	p.mu.Lock()
+	if maxConnections <= p.ReservedConnections {
+		p.mu.Unlock()
+		return fmt.Errorf("%w: max connections must be greater than the %d reserved connections, got %d",
+			ErrInvalidConfig, p.ReservedConnections, maxConnections)
+	}
+	if err := p.tenants.fits(maxConnections - p.ReservedConnections); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	p.MaxConnections = maxConnections
+	p.storeSettings()
+	for p.ActiveConns-len(surplus) > maxConnections {
+		idle, ok := p.idle.PopColdest()
+		if !ok {
+			break
+		}
+		surplus = append(surplus, idle.Conn)
+	}
+	p.overLimit.Store(p.ActiveConns-len(surplus) > maxConnections)
+	p.notifyLocked()
+	p.mu.Unlock()
+
+	for _, conn := range surplus {
+		p.closeConn(conn, "Closing surplus idle connection after resize")
+	}
+	return nil
+}
+
+// Reconfigure applies the tunable settings of c to the running pool: MaxConnections (as by Resize),
+// ConnTimeout, IdleTimeout, MaxRetries, Backoff, DoRetries, CheckDirtyOnRelease, ReservedConnections,
+// the tenant quotas, which replace those set with SetTenantQuota, and the health check settings,
+// which apply from the next interval, counted from now; a nil Probe keeps the current one. The
+// health check itself cannot be turned on or off. The pool's name, hooks, dialer, TLS, endpoints,
+// leak detection, outlier detection and autoscaling settings are fixed at creation and ignored;
+// an autoscaled pool carries on from the new MaxConnections.
+//
+// Parameters:
+//   - c: The new configuration. It must be valid and target the pool's network and address.
+//
+// Returns:
+//   - An error, if c is invalid, targets a different endpoint, or adds or removes the health check.
+func (p *ConnectionPool) Reconfigure(c ConfigImpl) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.Address != p.Address || (c.Network != "" && c.Network != p.Network) {
+		return fmt.Errorf("%w: cannot move pool %q from %s %s to %s %s",
+			ErrInvalidConfig, p.Name, p.Network, p.Address, c.Network, c.Address)
+	}
+	if (c.HealthCheck == nil) != (p.settings().healthCheck == nil) {
+		return fmt.Errorf("%w: the health check of pool %q cannot be turned on or off while it is in use", ErrInvalidConfig, p.Name)
+	}
+
+	p.mu.Lock()
+	p.ConnTimeout = c.ConnTimeout
+	p.IdleTimeout = c.IdleTimeout
+	p.MaxRetries = c.MaxRetries
+	p.Backoff = c.Backoff
+	p.DoRetries = c.DoRetries
+	p.CheckDirtyOnRelease = c.CheckDirtyOnRelease
+	p.ReservedConnections = c.ReservedConnections
+	if c.HealthCheck != nil {
+		h := c.HealthCheck.withDefaults()
+		if h.Probe == nil {
+			// Configuration files cannot carry a probe, so a reload keeps the one in use.
+			h.Probe = p.healthCheck.Probe
+		}
+		p.healthCheck = &h
+	}
+	p.storeSettings()
+	p.mu.Unlock()
+	if c.HealthCheck != nil {
+		select {
+		case p.rechecked <- struct{}{}:
+		default:
+		}
+	}
+	p.tenants.set(c.TenantQuotas, c.DefaultTenantQuota)
+
+	return p.Resize(c.MaxConnections)
+}
+
+// notifyLocked wakes every caller waiting for an idle connection or for capacity.
+// p.mu must be held.
+func (p *ConnectionPool) notifyLocked() {
+	if p.waiters.Load() == 0 {
+		return
+	}
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+// wakeWaiters wakes the waiting callers, if any, after a connection became idle.
+// It only takes p.mu when someone is waiting.
+func (p *ConnectionPool) wakeWaiters() {
+	if p.waiters.Load() == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.notifyLocked()
+	p.mu.Unlock()
+}
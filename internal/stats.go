@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// DiscardReason explains why a connection was taken out of circulation instead of being reused.
+type DiscardReason string
+
+const (
+	DiscardExplicit DiscardReason = "explicit" // The caller asked for the connection to be discarded
+	DiscardError    DiscardReason = "error"    // The caller reported an error that broke the stream
+	DiscardPanic    DiscardReason = "panic"    // A callback panicked while using the connection
+	DiscardDirty    DiscardReason = "dirty"    // The connection was released with unread data pending
+	DiscardRejected DiscardReason = "rejected" // An OnBorrow or OnReturn hook rejected the connection
+)
+
+// Stats is a point-in-time snapshot of a pool's connection counts and lifetime counters.
+type Stats struct {
+	Open  int // Connections currently open, idle or in use
+	Idle  int // Connections waiting in the pool
+	InUse int // Connections checked out by callers
+
+	Created    uint64                   // Connections successfully dialed
+	Closed     uint64                   // Connections closed by the pool
+	Acquired   uint64                   // Successful checkouts
+	Released   uint64                   // Connections returned to the idle pool
+	Discarded  map[DiscardReason]uint64 // Connections discarded, by reason
+	DialErrors uint64                   // Acquisitions that failed to establish a connection
+	Leaked     uint64                   // Leak reports
+	KeyHits    uint64                   // GetForKey calls served by a connection last used with the key
+	KeyMisses  uint64                   // GetForKey calls served by another connection
+
+	Keys map[string][]uint64 // IDs of the open connections last used with each session key, see ConnInfo
+
+	Waits   map[Priority]WaitStats // Acquisition wait times, by priority class
+	Tenants map[string]TenantStats // Use of the pool by each tenant given a quota, or holding or waiting for a connection
+
+	Autoscale *AutoscaleStats          // State of the autoscaler, or nil if the pool is not autoscaled
+	Endpoints map[string]EndpointStats // Health of each endpoint, or nil for a pool without Endpoints or a health check
+
+	EventsDropped uint64 // Events not delivered because a subscriber's queue was full
+}
+
+// counters holds the lifetime counters behind Stats.
+type counters struct {
+	created    atomic.Uint64
+	closed     atomic.Uint64
+	acquired   atomic.Uint64
+	released   atomic.Uint64
+	dialErrors atomic.Uint64
+	leaked     atomic.Uint64
+	keyHits    atomic.Uint64
+	keyMisses  atomic.Uint64
+}
+
+// Stats returns a snapshot of the pool's current state and counters.
+//
+// Returns:
+//   - A Stats value.
+func (p *ConnectionPool) Stats() Stats {
+	p.mu.Lock()
+	discarded := make(map[DiscardReason]uint64, len(p.discarded))
+	for reason, n := range p.discarded {
+		discarded[reason] = n
+	}
+	s := Stats{
+		Open:      p.ActiveConns,
+		Idle:      p.idle.Len(),
+		InUse:     int(p.inUse.Load()),
+		Discarded: discarded,
+	}
+	p.mu.Unlock()
+
+	s.Created = p.counters.created.Load()
+	s.Closed = p.counters.closed.Load()
+	s.Acquired = p.counters.acquired.Load()
+	s.Released = p.counters.released.Load()
+	s.DialErrors = p.counters.dialErrors.Load()
+	s.Leaked = p.counters.leaked.Load()
+	s.KeyHits = p.counters.keyHits.Load()
+	s.KeyMisses = p.counters.keyMisses.Load()
+	s.Keys = p.keyedConns()
+	s.Waits = p.waitStats()
+	s.Tenants = p.tenants.stats()
+	s.Endpoints = p.endpointStats()
+	if p.scaler != nil {
+		s.Autoscale = p.scaler.stats(p.settings().maxConnections)
+	}
+	s.EventsDropped = p.events.dropped.Load()
+	return s
+}
+
+// InUse returns the number of connections checked out by callers. Unlike Stats it takes no
+// lock and builds no snapshot, so it is cheap enough to poll across many pools.
+//
+// Returns:
+//   - The number of outstanding checkouts.
+func (p *ConnectionPool) InUse() int {
+	return int(p.inUse.Load())
+}
+
+// closeConn closes a raw connection owned by the pool and accounts for it.
+//
+// Parameters:
+//   - conn: The raw connection to close.
+//   - why: The message logged when no OnConnectionClose hook is set.
+//
+// Returns:
+//   - The error returned by Close.
+func (p *ConnectionPool) closeConn(conn net.Conn, why string) error {
+	p.mu.Lock()
+	p.ActiveConns--
+	if p.overLimit.Load() {
+		p.overLimit.Store(p.ActiveConns > p.MaxConnections)
+	}
+	p.notifyLocked()
+	p.mu.Unlock()
+	if p.limiter != nil {
+		p.limiter.release()
+	}
+	p.counters.closed.Add(1)
+	p.publish(Event{Type: EventConnectionClose, Conn: conn})
+
+	if p.Hooks.OnConnectionClose != nil {
+		p.callHook("OnConnectionClose", func() { p.Hooks.OnConnectionClose(conn) })
+	} else {
+		fmt.Println(why)
+	}
+	p.conns.Delete(conn)
+	return conn.Close()
+}
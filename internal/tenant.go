@@ -0,0 +1,372 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQuotaExceeded is matched by the error returned when a tenant already borrows as many
+// connections as its quota allows.
+var ErrQuotaExceeded = errors.New("tcppool: tenant quota exceeded")
+
+// TenantQuota bounds the connections a tenant borrows at once.
+type TenantQuota struct {
+	Max int `json:"max,omitempty"` // Connections the tenant may borrow or wait for at once; 0 means no limit
+	Min int `json:"min,omitempty"` // Connections kept available for the tenant while it borrows fewer; 0 means none
+}
+
+// validate checks the quota of tenant against a pool of maxConnections connections.
+func (q TenantQuota) validate(tenant string, maxConnections int) error {
+	switch {
+	case q.Max < 0 || q.Min < 0:
+		return fmt.Errorf("%w: quota of tenant %q must not be negative", ErrInvalidConfig, tenant)
+	case q.Max > 0 && q.Min > q.Max:
+		return fmt.Errorf("%w: guaranteed minimum of tenant %q exceeds its maximum", ErrInvalidConfig, tenant)
+	case q.Min > maxConnections:
+		return fmt.Errorf("%w: guaranteed minimum of tenant %q exceeds max connections", ErrInvalidConfig, tenant)
+	}
+	return nil
+}
+
+// checkMinimums checks that the guaranteed minimums of quotas fit in the connections shared by the
+// callers below PriorityHigh, so that callers without a tenant are not starved.
+//
+// Parameters:
+//   - quotas: The quotas by tenant ID.
+//   - shared: MaxConnections minus ReservedConnections.
+//
+// Returns:
+//   - An error matching ErrInvalidConfig, if the minimums add up to more than shared.
+func checkMinimums(quotas map[string]TenantQuota, shared int) error {
+	sum := 0
+	for _, q := range quotas {
+		sum += q.Min
+	}
+	if sum > shared {
+		return fmt.Errorf("%w: guaranteed minimums of the tenants add up to %d, more than the %d connections "+
+			"max connections leaves beside the reserved ones", ErrInvalidConfig, sum, shared)
+	}
+	return nil
+}
+
+// TenantStats reports a tenant's use of the pool.
+type TenantStats struct {
+	Quota    TenantQuota // The quota in effect
+	InUse    int         // Connections the tenant holds or is about to take
+	Waiting  int         // Callers of the tenant waiting for a connection
+	Acquired uint64      // Successful acquisitions
+	Rejected uint64      // Acquisitions refused with ErrQuotaExceeded
+}
+
+// tenantKey is the context key of the caller's tenant.
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant ID, for GetContext and the other
+// context-taking acquisition methods.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - tenant: The tenant the acquisitions made with the returned context are made for.
+//
+// Returns:
+//   - The derived context.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant ID carried by ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The tenant set with ContextWithTenant, or "" for none.
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenant is the accounting of one tenant.
+type tenant struct {
+	quota    TenantQuota
+	active   int // Acquisitions in progress or holding a connection, checked against quota.Max
+	held     int // Connections held or about to be taken, checked against quota.Min
+	acquired uint64
+	rejected uint64
+}
+
+// unmet returns how many connections the tenant's guaranteed minimum still asks for.
+func (tn *tenant) unmet() int {
+	return max(tn.quota.Min-tn.held, 0)
+}
+
+// tenants tracks the tenants of a pool and their quotas. Tenants with their own quota are always
+// known; the others only while they hold or wait for a connection, so that request- or user-scoped
+// tenant IDs do not accumulate.
+type tenants struct {
+	mu       sync.Mutex
+	quotas   map[string]TenantQuota
+	fallback TenantQuota // Quota of the tenants without their own; its Min is always 0
+	byID     map[string]*tenant
+	unmet    int          // Sum of the tenants' unmet guaranteed minimums
+	minimums atomic.Int32 // Number of tenants with a guaranteed minimum
+}
+
+// quotaOf returns the quota in effect for a tenant. t.mu must be held.
+func (t *tenants) quotaOf(id string) TenantQuota {
+	if q, ok := t.quotas[id]; ok {
+		return q
+	}
+	return t.fallback
+}
+
+// set replaces the quotas and applies them to the known tenants.
+//
+// Parameters:
+//   - quotas: The quotas by tenant ID.
+//   - fallback: The quota of the tenants without their own.
+func (t *tenants) set(quotas map[string]TenantQuota, fallback TenantQuota) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quotas = maps.Clone(quotas)
+	if t.quotas == nil {
+		t.quotas = make(map[string]TenantQuota)
+	}
+	t.fallback = fallback
+	t.applyLocked()
+}
+
+// setOne sets or, if remove is true, removes the quota of a single tenant.
+//
+// Parameters:
+//   - id: The tenant ID.
+//   - quota: The tenant's quota.
+//   - remove: Whether to remove the tenant's own quota instead.
+//   - shared: MaxConnections minus ReservedConnections, which the guaranteed minimums must fit in.
+//
+// Returns:
+//   - An error matching ErrInvalidConfig, if the minimums would no longer fit.
+func (t *tenants) setOne(id string, quota TenantQuota, remove bool, shared int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if remove {
+		delete(t.quotas, id)
+	} else {
+		previous, had := t.quotas[id]
+		t.quotas[id] = quota
+		if err := checkMinimums(t.quotas, shared); err != nil {
+			if had {
+				t.quotas[id] = previous
+			} else {
+				delete(t.quotas, id)
+			}
+			return err
+		}
+	}
+	t.applyLocked()
+	return nil
+}
+
+// fits checks that the guaranteed minimums fit in shared connections, e.g. before a resize.
+//
+// Parameters:
+//   - shared: MaxConnections minus ReservedConnections.
+//
+// Returns:
+//   - An error matching ErrInvalidConfig, if they do not.
+func (t *tenants) fits(shared int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return checkMinimums(t.quotas, shared)
+}
+
+// applyLocked refreshes the quota of every tenant, forgets the idle tenants that lost their own
+// quota and recounts the unmet minimums. t.mu must be held.
+func (t *tenants) applyLocked() {
+	if t.byID == nil {
+		t.byID = make(map[string]*tenant)
+	}
+	for id := range t.quotas {
+		if t.byID[id] == nil {
+			t.byID[id] = &tenant{}
+		}
+	}
+	var minimums int32
+	t.unmet = 0
+	for id, tn := range t.byID {
+		if _, own := t.quotas[id]; !own && tn.active == 0 {
+			delete(t.byID, id)
+			continue
+		}
+		tn.quota = t.quotaOf(id)
+		if tn.quota.Min > 0 {
+			minimums++
+		}
+		t.unmet += tn.unmet()
+	}
+	t.minimums.Store(minimums)
+}
+
+// enter starts an acquisition for a tenant, refusing it if the tenant is at its maximum.
+//
+// Parameters:
+//   - id: The tenant ID, or "" for none.
+//
+// Returns:
+//   - An error matching ErrQuotaExceeded, if the tenant is at its maximum.
+func (t *tenants) enter(id string) error {
+	if id == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tn := t.byID[id]
+	if tn == nil {
+		tn = &tenant{quota: t.quotaOf(id)}
+		t.byID[id] = tn
+	}
+	if tn.quota.Max > 0 && tn.active >= tn.quota.Max {
+		tn.rejected++
+		return fmt.Errorf("%w: tenant %q already borrows %d connections", ErrQuotaExceeded, id, tn.active)
+	}
+	tn.active++
+	return nil
+}
+
+// leave ends an acquisition started by enter: when it fails, or when the connection it got
+// is handed back.
+//
+// Parameters:
+//   - id: The tenant ID, or "" for none.
+func (t *tenants) leave(id string) {
+	if id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tn := t.byID[id]
+	tn.active--
+	if _, own := t.quotas[id]; !own && tn.active == 0 {
+		delete(t.byID, id)
+	}
+}
+
+// acquired counts a successful acquisition of a tenant.
+//
+// Parameters:
+//   - id: The tenant ID, or "" for none.
+func (t *tenants) acquired(id string) {
+	if id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byID[id].acquired++
+}
+
+// admit lets a caller take a connection unless that would eat into the capacity guaranteed to
+// other tenants: a tenant below its minimum is always admitted, anyone else only while the
+// connections held plus the unmet minimums stay below maxConnections.
+//
+// Parameters:
+//   - id: The caller's tenant ID, or "" for none.
+//   - held: The pool's count of held connections, incremented on success.
+//   - maxConnections: The MaxConnections in effect.
+//
+// Returns:
+//   - Whether the caller may take a connection.
+func (t *tenants) admit(id string, held *atomic.Int64, maxConnections int) bool {
+	if id == "" && t.minimums.Load() == 0 {
+		held.Add(1)
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tn := t.byID[id]
+	if (tn == nil || tn.held >= tn.quota.Min) && int(held.Load())+t.unmet >= maxConnections {
+		return false
+	}
+	if tn != nil {
+		t.unmet -= tn.unmet()
+		tn.held++
+		t.unmet += tn.unmet()
+	}
+	held.Add(1)
+	return true
+}
+
+// unadmit gives back what admit counted.
+//
+// Parameters:
+//   - id: The caller's tenant ID, or "" for none.
+//   - held: The pool's count of held connections.
+func (t *tenants) unadmit(id string, held *atomic.Int64) {
+	held.Add(-1)
+	if id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tn := t.byID[id]
+	t.unmet -= tn.unmet()
+	tn.held--
+	t.unmet += tn.unmet()
+}
+
+// stats returns the stats of every known tenant.
+//
+// Returns:
+//   - The stats by tenant ID, or nil if no tenant is known.
+func (t *tenants) stats() map[string]TenantStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.byID) == 0 {
+		return nil
+	}
+	stats := make(map[string]TenantStats, len(t.byID))
+	for id, tn := range t.byID {
+		stats[id] = TenantStats{
+			Quota:    tn.quota,
+			InUse:    tn.held,
+			Waiting:  tn.active - tn.held,
+			Acquired: tn.acquired,
+			Rejected: tn.rejected,
+		}
+	}
+	return stats
+}
+
+// SetTenantQuota sets the quota of a tenant while the pool is in use. Callers of other tenants
+// waiting for capacity the tenant no longer needs are woken.
+//
+// Parameters:
+//   - tenant: The tenant ID.
+//   - quota: The tenant's quota.
+//
+// Returns:
+//   - An error, if the quota is invalid or the guaranteed minimums would no longer fit in the
+//     connections left beside the reserved ones.
+func (p *ConnectionPool) SetTenantQuota(tenant string, quota TenantQuota) error {
+	cfg := p.settings()
+	if err := quota.validate(tenant, cfg.maxConnections); err != nil {
+		return err
+	}
+	if err := p.tenants.setOne(tenant, quota, false, cfg.maxConnections-cfg.reserved); err != nil {
+		return err
+	}
+	p.wakeWaiters()
+	return nil
+}
+
+// RemoveTenantQuota makes a tenant fall back to the default tenant quota.
+//
+// Parameters:
+//   - tenant: The tenant ID.
+func (p *ConnectionPool) RemoveTenantQuota(tenant string) {
+	p.tenants.setOne(tenant, TenantQuota{}, true, 0)
+	p.wakeWaiters()
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSimpleYAML parses the YAML subset used by pool configuration files: nested mappings
+// expressed by space indentation, scalar values (strings, quoted strings, integers, floats and
+// booleans), sequences of scalars, either as "- item" lines or as [a, b], and # comments.
+// Sequences of mappings, anchors and multi-line scalars are not supported.
+//
+// Parameters:
+//   - data: The document to parse.
+//
+// Returns:
+//   - The document as nested maps.
+//   - An error naming the offending line, if the document is not in the supported subset.
+func ParseSimpleYAML(data []byte) (map[string]any, error) {
+	type level struct {
+		indent int
+		m      map[string]any
+		parent map[string]any // The mapping holding m, and its key, to turn m into a sequence
+		key    string
+		seq    bool // Whether the level holds "- item" lines rather than a mapping
+	}
+	root := map[string]any{}
+	stack := []level{{indent: -1, m: root}}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "---" {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNo)
+		}
+		indent := len(line) - len(trimmed)
+
+		if item, ok := strings.CutPrefix(strings.TrimRight(trimmed, " "), "-"); ok && (item == "" || item[0] == ' ') {
+			// An item belongs to the key opened on a line above, at the same or a lower indentation.
+			for stack[len(stack)-1].indent > indent {
+				stack = stack[:len(stack)-1]
+			}
+			top := &stack[len(stack)-1]
+			if top.parent == nil || (!top.seq && len(top.m) > 0) {
+				return nil, fmt.Errorf("line %d: sequence item outside of a sequence", lineNo)
+			}
+			item = strings.TrimSpace(item)
+			if item == "" {
+				return nil, fmt.Errorf("line %d: empty sequence item", lineNo)
+			}
+			if key, _, _ := splitKeyValue(item); key != "" && !isQuoted(item) {
+				return nil, fmt.Errorf("line %d: sequences of mappings are not supported", lineNo)
+			}
+			scalar, err := ParseScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if !top.seq {
+				top.seq = true
+				top.parent[top.key] = []any{}
+			}
+			top.parent[top.key] = append(top.parent[top.key].([]any), scalar)
+			continue
+		}
+
+		key, value, nested := splitKeyValue(strings.TrimRight(trimmed, " "))
+		if key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if stack[len(stack)-1].seq {
+			return nil, fmt.Errorf("line %d: expected a sequence item", lineNo)
+		}
+		parent := stack[len(stack)-1].m
+		if _, dup := parent[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+
+		if nested {
+			child := map[string]any{}
+			parent[key] = child
+			stack = append(stack, level{indent: indent, m: child, parent: parent, key: key})
+			continue
+		}
+		if strings.HasPrefix(value, "[") {
+			items, err := parseFlowSequence(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			parent[key] = items
+			continue
+		}
+		scalar, err := ParseScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		parent[key] = scalar
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// ParseScalar converts a textual scalar into a bool, int64, float64 or string.
+// Quoted values are always strings.
+//
+// Parameters:
+//   - s: The scalar text.
+//
+// Returns:
+//   - The typed value.
+//   - An error, if a quoted value is malformed.
+func ParseScalar(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2:
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// parseFlowSequence parses a sequence of scalars written as [a, b, "c"].
+func parseFlowSequence(s string) ([]any, error) {
+	inner, ok := strings.CutSuffix(strings.TrimPrefix(s, "["), "]")
+	if !ok {
+		return nil, fmt.Errorf("unterminated sequence %s", s)
+	}
+	items := []any{}
+	if strings.TrimSpace(inner) == "" {
+		return items, nil
+	}
+	var quote rune
+	start := 0
+	for i, r := range inner + "," {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			scalar, err := ParseScalar(strings.TrimSpace(inner[start:i]))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, scalar)
+			start = i + 1
+		}
+	}
+	return items, nil
+}
+
+// isQuoted reports whether s is a quoted scalar.
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'")
+}
+
+// splitKeyValue splits "key: value" or "key:" (a nested mapping).
+func splitKeyValue(s string) (key, value string, nested bool) {
+	if strings.HasSuffix(s, ":") && !strings.Contains(s, ": ") {
+		return strings.TrimSpace(strings.TrimSuffix(s, ":")), "", true
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+2:]), false
+}
+
+// stripComment removes a # comment that is not inside a quoted string.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
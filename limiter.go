@@ -0,0 +1,32 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// Limiter caps the number of connections open across every pool configured with it, for example
+// to keep a process with many pools within its file descriptor limit. Dials waiting for capacity are
+// served in FIFO order, and a pool that needs capacity closes an idle connection of the pool holding
+// the most idle connections.
+type Limiter = internal.Limiter
+
+// LimiterStats is a point-in-time snapshot of a Limiter.
+type LimiterStats = internal.LimiterStats
+
+// NewLimiter creates a Limiter allowing up to max open connections across its pools.
+//
+// Parameters:
+//   - max: The connection limit, greater than zero.
+//
+// Returns:
+//   - A pointer to the created Limiter.
+//   - An error, if max is not positive.
+func NewLimiter(max int) (*Limiter, error) {
+	return internal.NewLimiter(max)
+}
+
+// WithLimiter makes the pool count its connections against l, which can be shared with other pools.
+// A pool's own MaxConnections still applies.
+func WithLimiter(l *Limiter) Option {
+	return func(c *Config) {
+		c.impl.Limiter = l
+	}
+}
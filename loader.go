@@ -0,0 +1,364 @@
+package tcppool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/internal/backoff"
+)
+
+// EnvPrefix starts every environment variable read by the configuration loaders.
+// A setting of the pool named "cache" is read from TCPPOOL_CACHE_<SETTING>, e.g.
+// TCPPOOL_CACHE_MAX_CONNECTIONS=20, TCPPOOL_CACHE_IDLE_TIMEOUT=30s or TCPPOOL_CACHE_BACKOFF=fibonacci
+// with TCPPOOL_CACHE_BACKOFF_MAX_DELAY=10. The settings of a section are read from
+// TCPPOOL_CACHE_<SECTION>_<SETTING>, e.g. TCPPOOL_CACHE_HEALTH_CHECK_INTERVAL=5s or
+// TCPPOOL_CACHE_AUTOSCALE_MAX=40, and setting one of them enables the section. Endpoints are
+// comma-separated, as in TCPPOOL_CACHE_ENDPOINTS=host1:11211,host2:11211, and tenant quotas are
+// a JSON object, as in TCPPOOL_CACHE_TENANT_QUOTAS={"acme": {"max": 4}}.
+const EnvPrefix = "TCPPOOL_"
+
+// envKeys maps environment variable suffixes to configuration fields.
+var envKeys = map[string]string{
+	"NAME":                   "name",
+	"NETWORK":                "network",
+	"ADDRESS":                "address",
+	"MAX_CONNECTIONS":        "max_connections",
+	"CONN_TIMEOUT":           "conn_timeout",
+	"IDLE_TIMEOUT":           "idle_timeout",
+	"MAX_RETRIES":            "max_retries",
+	"LEAK_THRESHOLD":         "leak_threshold",
+	"LEAK_STACK_SAMPLE_RATE": "leak_stack_sample_rate",
+	"DO_RETRIES":             "do_retries",
+	"DIRTY_CHECK":            "dirty_check",
+	"REPANIC_HOOKS":          "repanic_hooks",
+	"IDLE_ORDER":             "idle_order",
+	"VALIDATE_CONCURRENCY":   "validate_concurrency",
+	"RESERVED_CONNECTIONS":   "reserved_connections",
+	"ENDPOINTS":              "endpoints",
+	"TENANT_QUOTAS":          "tenant_quotas",
+}
+
+// envSections maps environment variable prefixes to the configuration sections whose settings
+// they hold, such as HEALTH_CHECK_INTERVAL for the health check's interval. The backoff, whose
+// type is set on its own, is handled separately.
+var envSections = map[string]string{
+	"HEALTH_CHECK_":         "health_check",
+	"OUTLIER_DETECTION_":    "outlier_detection",
+	"AUTOSCALE_":            "autoscale",
+	"DEFAULT_TENANT_QUOTA_": "default_tenant_quota",
+}
+
+// stringFields are kept verbatim rather than parsed as numbers or booleans.
+var stringFields = map[string]bool{
+	"name":           true,
+	"network":        true,
+	"address":        true,
+	"conn_timeout":   true,
+	"idle_timeout":   true,
+	"leak_threshold": true,
+	"idle_order":     true,
+}
+
+// LoadConfigs reads pool configurations from a file and the environment.
+// The file holds a "pools" mapping from pool name to settings, in JSON or in a YAML subset
+// (chosen by the .json, .yaml or .yml extension), using the field names of Config.MarshalJSON:
+//
+//	pools:
+//	  cache:
+//	    address: localhost:11211
+//	    endpoints:
+//	      - localhost:11212
+//	    max_connections: 20
+//	    idle_timeout: 30s
+//	    backoff:
+//	      type: exponential
+//	      base: 1
+//	      max_delay: 10
+//
+// Environment variables (see EnvPrefix) override the file's settings, and pools defined only
+// in the environment are included under their lower-cased name. A file pool is matched by its
+// name in environment form, so TCPPOOL_MY_CACHE_ADDRESS overrides the pool "my-cache". An empty
+// path loads from the environment alone.
+//
+// Parameters:
+//   - path: The configuration file, or "" for none.
+//   - opts: Options applied to every configuration before the loaded settings, e.g. WithHooks.
+//
+// Returns:
+//   - The configurations keyed by pool name.
+//   - An error, if the file cannot be read or a setting is malformed.
+func LoadConfigs(path string, opts ...Option) (map[string]*Config, error) {
+	configs := make(map[string]*Config)
+	if path != "" {
+		pools, err := readPoolsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, settings := range pools {
+			c, err := buildConfig(name, settings, opts)
+			if err != nil {
+				return nil, fmt.Errorf("pool %q in %s: %w", name, path, err)
+			}
+			configs[name] = c
+		}
+	}
+
+	// Environment names are matched against the file's pool names in their environment form,
+	// so MY_CACHE overrides the file's my-cache rather than defining a second pool.
+	known := make(map[string]bool, len(configs))
+	for name := range configs {
+		known[envName(name)] = true
+	}
+	for _, env := range envPoolNames(os.Environ()) {
+		if !known[env] {
+			name := strings.ToLower(env)
+			configs[name] = newBaseConfig(name, opts)
+		}
+	}
+	for name, c := range configs {
+		if err := applyEnv(c, name, os.Environ()); err != nil {
+			return nil, fmt.Errorf("pool %q: %w", name, err)
+		}
+	}
+	return configs, nil
+}
+
+// ConfigFromEnv builds the configuration of a single pool from TCPPOOL_<NAME>_* variables.
+//
+// Parameters:
+//   - name: The pool name; it is upper-cased and non-alphanumeric characters become underscores.
+//   - opts: Options applied before the environment settings.
+//
+// Returns:
+//   - The configuration.
+//   - An error, if no address is configured or a setting is malformed.
+func ConfigFromEnv(name string, opts ...Option) (*Config, error) {
+	c := newBaseConfig(name, opts)
+	if err := applyEnv(c, name, os.Environ()); err != nil {
+		return nil, fmt.Errorf("pool %q: %w", name, err)
+	}
+	if c.Address() == "" {
+		return nil, fmt.Errorf("pool %q: %s%s_ADDRESS is not set", name, EnvPrefix, envName(name))
+	}
+	return c, nil
+}
+
+// LoadPools creates a pool for every configuration returned by LoadConfigs.
+// If any pool fails to be created, none are returned.
+//
+// Parameters:
+//   - path: The configuration file, or "" to load from the environment alone.
+//   - opts: Options applied to every configuration before the loaded settings.
+//
+// Returns:
+//   - The pools keyed by name.
+//   - An error, if loading fails or a configuration is invalid.
+func LoadPools(path string, opts ...Option) (map[string]*Pool, error) {
+	configs, err := LoadConfigs(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	pools := make(map[string]*Pool, len(configs))
+	for name, c := range configs {
+		p, err := NewWithConfig(*c)
+		if err != nil {
+			return nil, fmt.Errorf("pool %q: %w", name, err)
+		}
+		pools[name] = p
+	}
+	return pools, nil
+}
+
+// readPoolsFile reads the "pools" mapping of a configuration file.
+func readPoolsFile(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		doc, err := internal.ParseSimpleYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	case ".json":
+	default:
+		if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			return nil, fmt.Errorf("%s: unknown configuration format, use a .json, .yaml or .yml file", path)
+		}
+	}
+
+	var doc struct {
+		Pools map[string]json.RawMessage `json:"pools"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc.Pools, nil
+}
+
+// newBaseConfig returns the configuration loaded settings are applied to.
+func newBaseConfig(name string, opts []Option) *Config {
+	c := NewConfigWith("", opts...)
+	c.impl.Name = name
+	return c
+}
+
+// buildConfig applies a pool's file settings on top of the options.
+func buildConfig(name string, settings json.RawMessage, opts []Option) (*Config, error) {
+	c := newBaseConfig(name, opts)
+	if err := c.UnmarshalJSON(settings); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// envName converts a pool name to its form in environment variable names.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// envPoolNames lists the pools that have an address set in the environment, in their
+// environment form (see envName).
+func envPoolNames(environ []string) []string {
+	var names []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, EnvPrefix) || !strings.HasSuffix(key, "_ADDRESS") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, EnvPrefix), "_ADDRESS")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// envSection returns the configuration section an environment variable suffix belongs to, or "".
+func envSection(suffix string) string {
+	for prefix, section := range envSections {
+		if strings.HasPrefix(suffix, prefix) && len(suffix) > len(prefix) {
+			return section
+		}
+	}
+	return ""
+}
+
+// applyEnv overrides c with the TCPPOOL_<NAME>_* variables found in environ.
+func applyEnv(c *Config, name string, environ []string) error {
+	prefix := EnvPrefix + envName(name) + "_"
+	settings := map[string]any{}
+	backoffSettings := map[string]any{}
+	sections := map[string]map[string]any{}
+
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		suffix, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case suffix == "BACKOFF":
+			backoffSettings["type"] = value
+		case strings.HasPrefix(suffix, "BACKOFF_"):
+			param := strings.ToLower(strings.TrimPrefix(suffix, "BACKOFF_"))
+			parsed, err := internal.ParseScalar(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			backoffSettings[param] = parsed
+		case envSection(suffix) != "":
+			section := envSection(suffix)
+			param := strings.ToLower(strings.TrimPrefix(suffix, strings.ToUpper(section)+"_"))
+			parsed, err := internal.ParseScalar(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if sections[section] == nil {
+				sections[section] = map[string]any{}
+			}
+			sections[section][param] = parsed
+		default:
+			field, known := envKeys[suffix]
+			if !known {
+				continue
+			}
+			switch field {
+			case "endpoints":
+				endpoints := []string{}
+				for _, addr := range strings.Split(value, ",") {
+					if addr = strings.TrimSpace(addr); addr != "" {
+						endpoints = append(endpoints, addr)
+					}
+				}
+				settings[field] = endpoints
+				continue
+			case "tenant_quotas":
+				if !json.Valid([]byte(value)) {
+					return fmt.Errorf("%s: tenant quotas must be a JSON object such as {\"acme\": {\"max\": 4}}", key)
+				}
+				settings[field] = json.RawMessage(value)
+				continue
+			}
+			if stringFields[field] {
+				settings[field] = value
+				continue
+			}
+			parsed, err := internal.ParseScalar(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			settings[field] = parsed
+		}
+	}
+
+	if len(backoffSettings) > 0 {
+		if _, ok := backoffSettings["type"]; !ok {
+			d, ok := c.impl.Backoff.(backoff.Describer)
+			if !ok {
+				return fmt.Errorf("%sBACKOFF must be set to configure backoff parameters", prefix)
+			}
+			current, params := d.Describe()
+			for k, v := range params {
+				if _, set := backoffSettings[k]; !set {
+					backoffSettings[k] = v
+				}
+			}
+			backoffSettings["type"] = current
+		}
+		settings["backoff"] = backoffSettings
+	}
+	for section, params := range sections {
+		settings[section] = params
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return c.UnmarshalJSON(data)
+}
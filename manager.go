@@ -0,0 +1,388 @@
+package tcppool
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/utils"
+)
+
+// Target identifies the endpoint a Manager pool connects to.
+// Pools are shared by targets with the same network, address and TLS configuration; TLS
+// configurations are compared by pointer, so reuse the same *tls.Config for the same identity.
+type Target struct {
+	Network   string      // Network to dial, or "" for the template's
+	Address   string      // Address to dial
+	TLSConfig *tls.Config // TLS client configuration, or nil for the template's
+}
+
+// String returns the target as "network://address", with a "+tls" suffix when TLS is used.
+func (t Target) String() string {
+	s := t.Network + "://" + t.Address
+	if t.TLSConfig != nil {
+		s += "+tls"
+	}
+	return s
+}
+
+// ManagerStats is a snapshot of every pool held by a Manager.
+type ManagerStats struct {
+	Pools  int              // Pools currently held
+	Reaped uint64           // Pools closed after going unused
+	Total  Stats            // Sum of the pools' stats
+	ByPool map[string]Stats // Stats of each pool, keyed by its ID (see Manager.PoolID)
+}
+
+// minReapInterval bounds how often a Manager looks for unused pools, however small its idle pool
+// timeout is.
+const minReapInterval = time.Millisecond
+
+// Manager lazily creates one pool per target and shares it between callers.
+// Every pool is built from the same template options. Pools that stay unused for the manager's
+// idle pool timeout, with no connection checked out, are closed and forgotten.
+type Manager struct {
+	opts        []Option
+	template    *Config
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	pools  map[Target]*managedPool
+	owners map[*internal.ConnectionPool]*managedPool
+	tlsIDs map[*tls.Config]int // Number of each TLS configuration seen, in order of first use
+	closed bool
+	done   chan struct{}
+	reaped atomic.Uint64
+}
+
+// managedPool is a pool held by a Manager, with what is needed to decide when to reap it.
+type managedPool struct {
+	target   Target
+	id       string // Key in ManagerStats.ByPool; see Manager.PoolID
+	pool     *Pool
+	lastUsed atomic.Int64 // Unix nanoseconds of the last Get or Release
+	getting  atomic.Int32 // Get calls in progress
+}
+
+// NewManager creates a Manager whose pools are configured by opts.
+// The address, and the name derived from it, are set per pool; every other setting, including
+// hooks, comes from the options.
+//
+// Parameters:
+//   - idlePoolTimeout: How long a pool may go unused before it is closed, or 0 to keep pools forever.
+//   - opts: The template options applied to every pool.
+//
+// Returns:
+//   - A pointer to the created Manager.
+func NewManager(idlePoolTimeout time.Duration, opts ...Option) *Manager {
+	m := &Manager{
+		opts:        opts,
+		template:    NewConfigWith("", opts...),
+		idleTimeout: idlePoolTimeout,
+		pools:       make(map[Target]*managedPool),
+		owners:      make(map[*internal.ConnectionPool]*managedPool),
+		tlsIDs:      make(map[*tls.Config]int),
+		done:        make(chan struct{}),
+	}
+	if idlePoolTimeout > 0 {
+		go m.reapIdlePools()
+	}
+	return m
+}
+
+// Get retrieves a connection to address from its pool, creating the pool on first use.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - address: The address to connect to.
+//
+// Returns:
+//   - A net.Conn representing the connection. Hand it back with Manager.Release.
+//   - An error, if the pool cannot be created or the connection retrieval fails.
+func (m *Manager) Get(ctx context.Context, address string) (net.Conn, error) {
+	return m.GetTarget(ctx, Target{Address: address})
+}
+
+// GetTarget is like Get, but can override the template's network and TLS configuration.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - target: The endpoint to connect to.
+//
+// Returns:
+//   - A net.Conn representing the connection. Hand it back with Manager.Release.
+//   - An error, if the pool cannot be created or the connection retrieval fails.
+func (m *Manager) GetTarget(ctx context.Context, target Target) (net.Conn, error) {
+	mp, err := m.lookup(target)
+	if err != nil {
+		return nil, err
+	}
+	defer mp.getting.Add(-1)
+	return mp.pool.GetContext(ctx)
+}
+
+// Release returns a connection obtained from Get to its pool.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//
+// Returns:
+//   - An error, if conn was not acquired from this manager or the release fails.
+func (m *Manager) Release(conn net.Conn) error {
+	mp, err := m.owner(conn)
+	if err != nil {
+		return err
+	}
+	return mp.pool.Release(conn)
+}
+
+// ReleaseWithError returns a connection to its pool, or discards it if err shows it is broken.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//   - err: The error observed while using the connection, or nil.
+//
+// Returns:
+//   - An error, if conn was not acquired from this manager or the release fails.
+func (m *Manager) ReleaseWithError(conn net.Conn, err error) error {
+	mp, ownerErr := m.owner(conn)
+	if ownerErr != nil {
+		return ownerErr
+	}
+	return mp.pool.ReleaseWithError(conn, err)
+}
+
+// Discard closes a connection obtained from Get instead of returning it to its pool.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//
+// Returns:
+//   - An error, if conn was not acquired from this manager or closing fails.
+func (m *Manager) Discard(conn net.Conn) error {
+	mp, err := m.owner(conn)
+	if err != nil {
+		return err
+	}
+	return mp.pool.Discard(conn)
+}
+
+// Stats returns the stats of every pool and their sum.
+//
+// Returns:
+//   - A ManagerStats value.
+func (m *Manager) Stats() ManagerStats {
+	m.mu.Lock()
+	pools := make([]*managedPool, 0, len(m.pools))
+	for _, mp := range m.pools {
+		pools = append(pools, mp)
+	}
+	m.mu.Unlock()
+
+	s := ManagerStats{
+		Pools:  len(pools),
+		Reaped: m.reaped.Load(),
+		Total:  Stats{Discarded: make(map[DiscardReason]uint64)},
+		ByPool: make(map[string]Stats, len(pools)),
+	}
+	for _, mp := range pools {
+		ps := mp.pool.Stats()
+		s.ByPool[mp.id] = ps
+		addStats(&s.Total, ps)
+	}
+	return s
+}
+
+// Close closes every pool and stops reaping. Later calls to Get fail with ErrPoolClosed.
+//
+// Returns:
+//   - nil; the error is reserved for future use.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.done)
+	pools := m.pools
+	m.pools = make(map[Target]*managedPool)
+	m.mu.Unlock()
+
+	for _, mp := range pools {
+		mp.pool.Close()
+	}
+	return nil
+}
+
+// PoolID returns the ID of the pool the manager uses for target, which keys ManagerStats.ByPool:
+// Target.String with the template's network and TLS configuration filled in, followed by "#n"
+// for the n-th TLS configuration the manager has seen, so that targets differing only by their
+// TLS configuration are told apart.
+//
+// Parameters:
+//   - target: The endpoint, as passed to GetTarget.
+//
+// Returns:
+//   - The pool ID.
+func (m *Manager) PoolID(target Target) string {
+	target = m.resolve(target)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.idLocked(target)
+}
+
+// resolve fills in the template's network and TLS configuration where target leaves them unset.
+func (m *Manager) resolve(target Target) Target {
+	if target.Network == "" {
+		target.Network = m.template.Network()
+	}
+	if target.TLSConfig == nil {
+		target.TLSConfig = m.template.impl.TLSConfig
+	}
+	return target
+}
+
+// idLocked returns the ID of a resolved target's pool. m.mu must be held.
+func (m *Manager) idLocked(target Target) string {
+	id := target.String()
+	if target.TLSConfig != nil {
+		n, ok := m.tlsIDs[target.TLSConfig]
+		if !ok {
+			n = len(m.tlsIDs) + 1
+			m.tlsIDs[target.TLSConfig] = n
+		}
+		id += "#" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// lookup returns the pool for target, creating it if needed, and marks a Get in progress on it.
+func (m *Manager) lookup(target Target) (*managedPool, error) {
+	target = m.resolve(target)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrPoolClosed
+	}
+	mp, ok := m.pools[target]
+	if !ok {
+		id := m.idLocked(target)
+		p, err := NewWithConfig(*m.configFor(target, id))
+		if err != nil {
+			return nil, fmt.Errorf("pool for %s: %w", id, err)
+		}
+		mp = &managedPool{target: target, id: id, pool: p}
+		m.pools[target] = mp
+		m.owners[p.impl] = mp
+	}
+	mp.getting.Add(1)
+	mp.lastUsed.Store(time.Now().UnixNano())
+	return mp, nil
+}
+
+// configFor builds the configuration of a target's pool from the template options.
+func (m *Manager) configFor(target Target, id string) *Config {
+	c := NewConfigWith(target.Address, m.opts...)
+	c.impl.Network = target.Network
+	c.impl.TLSConfig = target.TLSConfig
+	if target.Network == "tcp" && target.TLSConfig == nil {
+		c.impl.Name = utils.IDByAddress(target.Address)
+	} else {
+		c.impl.Name = utils.IDByAddress(id)
+	}
+	return c
+}
+
+// owner returns the managed pool a connection belongs to and marks it as used.
+func (m *Manager) owner(conn net.Conn) (*managedPool, error) {
+	impl := internal.Owner(conn)
+	m.mu.Lock()
+	mp, ok := m.owners[impl]
+	m.mu.Unlock()
+	if impl == nil || !ok {
+		return nil, ErrForeignConn
+	}
+	mp.lastUsed.Store(time.Now().UnixNano())
+	return mp, nil
+}
+
+// reapIdlePools periodically closes pools that have gone unused for the idle pool timeout.
+func (m *Manager) reapIdlePools() {
+	ticker := time.NewTicker(max(m.idleTimeout/2, minReapInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.done:
+			return
+		}
+
+		var idle []*managedPool
+		m.mu.Lock()
+		for target, mp := range m.pools {
+			unused := time.Since(time.Unix(0, mp.lastUsed.Load())) >= m.idleTimeout
+			if !unused || mp.getting.Load() > 0 || mp.pool.impl.InUse() > 0 {
+				continue
+			}
+			delete(m.pools, target)
+			delete(m.owners, mp.pool.impl)
+			idle = append(idle, mp)
+		}
+		m.mu.Unlock()
+
+		for _, mp := range idle {
+			m.reaped.Add(1)
+			fmt.Printf("Closing pool for %s after %v unused\n", mp.id, m.idleTimeout)
+			mp.pool.Close()
+		}
+	}
+}
+
+// addStats adds the counts of s to total.
+func addStats(total *Stats, s Stats) {
+	total.Open += s.Open
+	total.Idle += s.Idle
+	total.InUse += s.InUse
+	total.Created += s.Created
+	total.Closed += s.Closed
+	total.Acquired += s.Acquired
+	total.Released += s.Released
+	total.DialErrors += s.DialErrors
+	total.Leaked += s.Leaked
+	total.KeyHits += s.KeyHits
+	total.KeyMisses += s.KeyMisses
+	total.EventsDropped += s.EventsDropped
+	for reason, n := range s.Discarded {
+		total.Discarded[reason] += n
+	}
+	for id, ts := range s.Tenants {
+		if total.Tenants == nil {
+			total.Tenants = make(map[string]TenantStats)
+		}
+		t := total.Tenants[id]
+		t.InUse += ts.InUse
+		t.Waiting += ts.Waiting
+		t.Acquired += ts.Acquired
+		t.Rejected += ts.Rejected
+		total.Tenants[id] = t
+	}
+	if total.Waits == nil {
+		total.Waits = make(map[Priority]WaitStats)
+	}
+	for prio, w := range s.Waits {
+		t := total.Waits[prio]
+		t.Waiting += w.Waiting
+		t.Acquired += w.Acquired
+		t.TotalWait += w.TotalWait
+		t.MaxWait = max(t.MaxWait, w.MaxWait)
+		total.Waits[prio] = t
+	}
+}
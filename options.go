@@ -0,0 +1,243 @@
+package tcppool
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/internal/backoff"
+	"github.com/meliadamian17/tcppool/utils"
+)
+
+// Default settings used by New and NewConfigWith.
+const (
+	DefaultMaxConnections = 10
+	DefaultConnTimeout    = 5 * time.Second
+	DefaultIdleTimeout    = 30 * time.Second
+	DefaultMaxRetries     = 3
+)
+
+// Option customizes a Config built by New or NewConfigWith.
+type Option func(c *Config)
+
+// NewConfigWith creates a Config for address from the defaults and the given options.
+// Without options the pool is named after its address, holds up to DefaultMaxConnections
+// connections, dials over TCP with DefaultConnTimeout and DefaultMaxRetries attempts separated
+// by an exponential backoff (1s doubling up to 10s), and sweeps idle connections every
+// DefaultIdleTimeout.
+//
+// Parameters:
+//   - address: The network address for the pool's connections.
+//   - opts: Options customizing the configuration.
+//
+// Returns:
+//   - A pointer to the created Config object.
+func NewConfigWith(address string, opts ...Option) *Config {
+	c := &Config{impl: &internal.ConfigImpl{
+		Network:        "tcp",
+		Address:        address,
+		MaxConnections: DefaultMaxConnections,
+		ConnTimeout:    DefaultConnTimeout,
+		IdleTimeout:    DefaultIdleTimeout,
+		MaxRetries:     DefaultMaxRetries,
+		Backoff:        NewExponentialBackoff(1, 10),
+	}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.impl.Name == "" {
+		c.impl.Name = utils.IDByAddress(address)
+	}
+	return c
+}
+
+// WithName sets the pool's name. By default it is derived from the address.
+func WithName(name string) Option {
+	return func(c *Config) {
+		c.impl.Name = name
+	}
+}
+
+// WithMaxConnections sets the maximum number of connections the pool keeps.
+func WithMaxConnections(n int) Option {
+	return func(c *Config) {
+		c.impl.MaxConnections = n
+	}
+}
+
+// WithConnTimeout sets the timeout for a single dial attempt, including the TLS handshake.
+func WithConnTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.impl.ConnTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the interval at which idle connections are checked and cleaned up.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.impl.IdleTimeout = d
+	}
+}
+
+// WithMaxRetries sets the number of dial attempts made before giving up.
+func WithMaxRetries(n uint) Option {
+	return func(c *Config) {
+		c.impl.MaxRetries = n
+	}
+}
+
+// WithBackoff sets the strategy used to space out dial attempts.
+func WithBackoff(b backoff.Backoff) Option {
+	return func(c *Config) {
+		c.impl.Backoff = b
+	}
+}
+
+// WithHooks sets the pool's event hooks.
+func WithHooks(h PoolHooks) Option {
+	return func(c *Config) {
+		c.impl.Hooks = h.ToInternal()
+	}
+}
+
+// WithNetwork sets the network passed to the dialer, such as "tcp4", "tcp6" or "unix".
+func WithNetwork(network string) Option {
+	return func(c *Config) {
+		c.impl.Network = network
+	}
+}
+
+// WithDialer sets the function used to dial connections instead of a net.Dialer.
+// It is called once per attempt with a context bounded by the connection timeout.
+func WithDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return func(c *Config) {
+		c.impl.Dialer = dial
+	}
+}
+
+// WithTLSConfig makes the pool wrap every connection in a TLS client using cfg.
+// If cfg.ServerName is empty, the host part of the address is used.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Config) {
+		c.impl.TLSConfig = cfg
+	}
+}
+
+// WithLeakDetection enables leak reporting; see Config.SetLeakDetection.
+func WithLeakDetection(threshold time.Duration, stackSampleRate float64) Option {
+	return func(c *Config) {
+		c.SetLeakDetection(threshold, stackSampleRate)
+	}
+}
+
+// WithDoRetries enables retries in Pool.Do; see Config.SetDoRetries.
+func WithDoRetries(retries uint) Option {
+	return func(c *Config) {
+		c.SetDoRetries(retries)
+	}
+}
+
+// WithDirtyCheck enables dirty-connection detection on release; see Config.SetDirtyCheck.
+func WithDirtyCheck(enabled bool) Option {
+	return func(c *Config) {
+		c.SetDirtyCheck(enabled)
+	}
+}
+
+// WithRepanicHooks re-raises hook panics after reporting them; see Config.SetRepanicHooks.
+func WithRepanicHooks(enabled bool) Option {
+	return func(c *Config) {
+		c.SetRepanicHooks(enabled)
+	}
+}
+
+// IdleOrder selects which idle connection is reused first and which one is retired first.
+type IdleOrder = internal.IdleOrder
+
+const (
+	// IdleLIFO reuses the most recently released connection and lets unused ones expire,
+	// so the pool shrinks under low load. This is the default.
+	IdleLIFO = internal.IdleLIFO
+	// IdleFIFO rotates evenly through the idle connections, e.g. to spread load over backends
+	// behind a load balancer.
+	IdleFIFO = internal.IdleFIFO
+	// IdleRetireOldest reuses the most recently dialed connection and retires the oldest first.
+	IdleRetireOldest = internal.IdleRetireOldest
+)
+
+// WithIdleOrder sets the order in which idle connections are reused and retired.
+func WithIdleOrder(order IdleOrder) Option {
+	return func(c *Config) {
+		c.impl.IdleOrder = order
+	}
+}
+
+// WithValidateConcurrency sets how many idle connections are validated at once during the
+// periodic idle check. By default DefaultValidateConcurrency are.
+func WithValidateConcurrency(n int) Option {
+	return func(c *Config) {
+		c.impl.ValidateConcurrency = n
+	}
+}
+
+// DefaultValidateConcurrency is the number of idle connections validated at once by default.
+const DefaultValidateConcurrency = internal.DefaultValidateConcurrency
+
+// WithReservedConnections keeps n of the pool's MaxConnections connections for callers with
+// PriorityHigh: callers of a lower priority wait once they hold MaxConnections-n connections.
+func WithReservedConnections(n int) Option {
+	return func(c *Config) {
+		c.impl.ReservedConnections = n
+	}
+}
+
+// WithTenantQuota sets the quota of a tenant; see Pool.SetTenantQuota.
+func WithTenantQuota(tenant string, quota TenantQuota) Option {
+	return func(c *Config) {
+		if c.impl.TenantQuotas == nil {
+			c.impl.TenantQuotas = make(map[string]TenantQuota)
+		}
+		c.impl.TenantQuotas[tenant] = quota
+	}
+}
+
+// WithDefaultTenantQuota sets the quota of the tenants without their own. Only its Max may be set.
+func WithDefaultTenantQuota(quota TenantQuota) Option {
+	return func(c *Config) {
+		c.impl.DefaultTenantQuota = quota
+	}
+}
+
+// WithAutoscale lets the pool adjust MaxConnections between cfg.Min and cfg.Max from the backend's
+// latency and the callers queuing for connections. MaxConnections is the starting limit.
+func WithAutoscale(cfg AutoscaleConfig) Option {
+	return func(c *Config) {
+		c.impl.Autoscale = &cfg
+	}
+}
+
+// WithEndpoints adds endpoints that serve the same backend as the pool's address. New connections
+// are dialed to the address and the endpoints in turn, and a failed dial is retried on the next one.
+func WithEndpoints(addresses ...string) Option {
+	return func(c *Config) {
+		c.impl.Endpoints = append(c.impl.Endpoints, addresses...)
+	}
+}
+
+// WithOutlierDetection ejects the endpoints whose error rate or latency stands out; see OutlierConfig.
+// It requires WithEndpoints.
+func WithOutlierDetection(cfg OutlierConfig) Option {
+	return func(c *Config) {
+		c.impl.OutlierDetection = &cfg
+	}
+}
+
+// WithHealthCheck probes the pool's endpoints in the background so that Get skips the unhealthy
+// ones; see HealthCheckConfig.
+func WithHealthCheck(cfg HealthCheckConfig) Option {
+	return func(c *Config) {
+		c.impl.HealthCheck = &cfg
+	}
+}
@@ -1,6 +1,7 @@
 package tcppool
 
 import (
+	"context"
 	"net"
 
 	"github.com/meliadamian17/tcppool/internal"
@@ -12,7 +13,25 @@ type Pool struct {
 	impl *internal.ConnectionPool
 }
 
-// New creates a new Pool instance based on the given configuration.
+// PooledConn is the connection handle returned by Get. It can be used as a plain net.Conn and
+// must be handed back with Release exactly once.
+type PooledConn = internal.PooledConn
+
+// New creates a new Pool for address, configured by the given options.
+// Settings that are not set by an option use the defaults described on NewConfigWith.
+//
+// Parameters:
+//   - address: The network address for the pool's connections.
+//   - opts: Options customizing the pool.
+//
+// Returns:
+//   - A pointer to the created Pool.
+//   - An error, if the configuration is invalid or the pool initialization fails.
+func New(address string, opts ...Option) (*Pool, error) {
+	return NewWithConfig(*NewConfigWith(address, opts...))
+}
+
+// NewWithConfig creates a new Pool instance based on the given configuration.
 // It initializes an internal connection pool and returns a Pool object.
 //
 // Parameters:
@@ -20,8 +39,8 @@ type Pool struct {
 //
 // Returns:
 //   - A pointer to the created Pool.
-//   - An error, if the pool initialization fails.
-func New(c Config) (*Pool, error) {
+//   - An error, if the configuration is invalid or the pool initialization fails.
+func NewWithConfig(c Config) (*Pool, error) {
 	impl, err := internal.NewConnectionPool(*c.impl)
 	if err != nil {
 		return nil, err
@@ -39,42 +58,163 @@ func (p *Pool) Get() (net.Conn, error) {
 	return p.impl.Get()
 }
 
-// GetAsync retrieves a connection from the pool asynchronously.
-// It returns a channel through which the result (connection or error) will be sent once available.
+// GetContext retrieves a connection from the pool like Get, but gives up as soon as ctx is done.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
 //
 // Returns:
-//   - A read-only channel of a struct containing:
-//   - Conn: A net.Conn representing the connection.
-//   - Err: An error, if the connection retrieval fails.
-func (p *Pool) GetAsync() <-chan struct {
-	Conn net.Conn
-	Err  error
-} {
-	resultChan := make(chan struct {
-		Conn net.Conn
-		Err  error
-	})
+//   - A net.Conn representing the connection.
+//   - An error, if the connection retrieval fails or ctx is done first.
+func (p *Pool) GetContext(ctx context.Context) (net.Conn, error) {
+	return p.impl.GetContext(ctx)
+}
 
-	go func() {
-		conn, err := p.impl.Get()
-		resultChan <- struct {
-			Conn net.Conn
-			Err  error
-		}{Conn: conn, Err: err}
-		close(resultChan)
-	}()
+// GetForKey retrieves a connection like GetContext, preferring an idle connection last used with
+// key, to reuse server-side session state such as prepared statements or subscriptions. It falls
+// back to any idle connection or a new one. Stats reports which connections hold which key.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - key: The session key.
+//
+// Returns:
+//   - A net.Conn representing the connection.
+//   - An error, if the connection retrieval fails or ctx is done first.
+func (p *Pool) GetForKey(ctx context.Context, key string) (net.Conn, error) {
+	return p.impl.GetForKey(ctx, key)
+}
 
-	return resultChan
+// GetAsync retrieves a connection from the pool asynchronously.
+// It returns an Acquisition that can be waited on, polled or cancelled. A connection acquired
+// after the caller cancelled (or after ctx is done) is returned to the pool rather than leaked.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//
+// Returns:
+//   - A pointer to the pending Acquisition.
+func (p *Pool) GetAsync(ctx context.Context) *Acquisition {
+	return &Acquisition{impl: p.impl.GetAsync(ctx)}
 }
 
 // Release returns a previously acquired connection back to the pool.
 // If the pool is full, the connection is closed.
 //
 // Parameters:
-//   - conn: The connection to be released.
+//   - conn: The connection to be released, exactly as returned by Get.
 //
 // Returns:
 //   - An error, if the release process fails.
 func (p *Pool) Release(conn net.Conn) error {
 	return p.impl.Release(conn)
 }
+
+// Do acquires a connection, runs fn with it and hands the connection back to the pool.
+// The connection is released when fn succeeds or returns an application error, and discarded
+// when fn returns a network error or panics. Errors typical of a stale pooled connection
+// (reset, broken pipe, EOF) are retried on a fresh connection when retries are enabled
+// with Config.SetDoRetries.
+//
+// Parameters:
+//   - ctx: The context bounding acquisition and retry waits.
+//   - fn: The callback using the connection. It must not retain the connection.
+//
+// Returns:
+//   - The error returned by fn, or an acquisition or release error.
+func (p *Pool) Do(ctx context.Context, fn func(net.Conn) error) error {
+	return p.impl.Do(ctx, fn)
+}
+
+// DoValue is like Pool.Do for callbacks that produce a value.
+//
+// Parameters:
+//   - ctx: The context bounding acquisition and retry waits.
+//   - p: The pool to acquire the connection from.
+//   - fn: The callback using the connection. It must not retain the connection.
+//
+// Returns:
+//   - The value returned by the last call to fn.
+//   - The error returned by fn, or an acquisition or release error.
+func DoValue[T any](ctx context.Context, p *Pool, fn func(net.Conn) (T, error)) (T, error) {
+	var value T
+	err := p.Do(ctx, func(conn net.Conn) error {
+		var err error
+		value, err = fn(conn)
+		return err
+	})
+	return value, err
+}
+
+// ReleaseWithError returns a connection to the pool after the caller observed err on it.
+// If err indicates a broken stream (EOF, reset, timeout, closed connection...), the connection is
+// discarded instead so it is never handed to the next caller.
+//
+// Parameters:
+//   - conn: The connection to be released, exactly as returned by Get.
+//   - err: The error observed while using the connection, or nil.
+//
+// Returns:
+//   - An error, if the release process fails.
+func (p *Pool) ReleaseWithError(conn net.Conn, err error) error {
+	return p.impl.ReleaseWithError(conn, err)
+}
+
+// Discard closes a previously acquired connection instead of returning it to the pool.
+//
+// Parameters:
+//   - conn: The connection to dispose of, exactly as returned by Get.
+//
+// Returns:
+//   - An error, if the connection does not belong to the pool or closing fails.
+func (p *Pool) Discard(conn net.Conn) error {
+	return p.impl.Discard(conn)
+}
+
+// Stats returns a snapshot of the pool's connection counts and lifetime counters.
+//
+// Returns:
+//   - A Stats value.
+func (p *Pool) Stats() Stats {
+	return p.impl.Stats()
+}
+
+// Resize changes the maximum number of open connections while the pool is in use.
+// Growing admits waiting callers immediately; shrinking closes surplus idle connections and
+// closes checked-out ones above the limit as they are released.
+//
+// Parameters:
+//   - maxConnections: The new maximum, greater than zero.
+//
+// Returns:
+//   - An error, if maxConnections is not positive, not above ReservedConnections, or too small for
+//     the tenants' guaranteed minimums.
+func (p *Pool) Resize(maxConnections int) error {
+	return p.impl.Resize(maxConnections)
+}
+
+// Reconfigure applies the tunable settings of c to the running pool: max connections (as by Resize),
+// timeouts, retries, backoff, Do retries, the dirty check, reserved connections, the tenant
+// quotas, which replace those set with SetTenantQuota, and the health check settings, which apply
+// from the next interval; a nil Probe keeps the current one, so configuration reloaded from a file
+// keeps the probe set in code. The health check cannot be turned on or off. The name, hooks, dialer,
+// TLS, endpoints, leak detection, outlier detection and autoscaling settings of the pool are kept;
+// an autoscaled pool carries on from the new max connections.
+//
+// Parameters:
+//   - c: The new configuration, for the pool's network and address.
+//
+// Returns:
+//   - An error, if c is invalid, targets a different endpoint, or adds or removes the health check.
+func (p *Pool) Reconfigure(c Config) error {
+	return p.impl.Reconfigure(*c.impl)
+}
+
+// Close shuts the pool down: idle connections are closed immediately, checked-out connections
+// when they are released, and pending or later Get calls fail with ErrPoolClosed.
+//
+// Returns:
+//   - An error, if closing fails.
+func (p *Pool) Close() error {
+	return p.impl.Close()
+}
@@ -0,0 +1,38 @@
+package tcppool
+
+import (
+	"context"
+
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// Priority orders callers competing for the connections of a saturated pool: waiting callers of
+// a higher priority are served first, and only PriorityHigh callers may use the connections
+// reserved with WithReservedConnections.
+type Priority = internal.Priority
+
+const (
+	// PriorityLow is meant for background work, such as batch jobs.
+	PriorityLow = internal.PriorityLow
+	// PriorityNormal is the priority of callers that do not set one.
+	PriorityNormal = internal.PriorityNormal
+	// PriorityHigh is meant for latency-sensitive work, such as user-facing requests.
+	PriorityHigh = internal.PriorityHigh
+)
+
+// WaitStats reports how long the callers of a priority class waited for their connections.
+// Stats.Waits holds one per priority.
+type WaitStats = internal.WaitStats
+
+// ContextWithPriority returns a copy of ctx carrying prio. Pass it to GetContext, GetForKey, Do or
+// GetAsync to acquire connections with that priority.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - prio: The priority of the acquisitions made with the returned context.
+//
+// Returns:
+//   - The derived context.
+func ContextWithPriority(ctx context.Context, prio Priority) context.Context {
+	return internal.ContextWithPriority(ctx, prio)
+}
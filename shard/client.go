@@ -0,0 +1,394 @@
+// Package shard spreads keys over several servers, each with its own tcppool.Pool, the way
+// memcached-style clients do. Keys are mapped with ketama consistent hashing (compatible with
+// libketama and libmemcached, with virtual nodes and weights) or with rendezvous hashing.
+// Servers that keep failing can be ejected automatically; their keys are rehashed onto the
+// remaining servers until the server is retried.
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// Algorithm selects how keys are mapped onto servers.
+type Algorithm string
+
+const (
+	// Ketama places 160 points per server (scaled by weight) on a hash ring; a key belongs to the
+	// server owning the next point. This is the default.
+	Ketama Algorithm = "ketama"
+	// Rendezvous gives a key to the server with the highest weighted hash of key and server.
+	// It needs no ring, at the cost of hashing against every server on each lookup.
+	Rendezvous Algorithm = "rendezvous"
+)
+
+// DefaultRetryAfter is how long an ejected server stays out when Config.RetryAfter is not set.
+const DefaultRetryAfter = 30 * time.Second
+
+// ErrNoServers is returned when every server has been ejected.
+var ErrNoServers = errors.New("shard: no servers available")
+
+// errUnknownConn is returned for connections that were not acquired from the client.
+var errUnknownConn = errors.New("shard: connection was not acquired from this client")
+
+// Server is an endpoint taking part in the distribution.
+type Server struct {
+	Address string // Address of the server, also used to place it on the ring
+	Weight  int    // Relative share of the keys; 0 means 1
+}
+
+// Config configures a Client.
+type Config struct {
+	// Algorithm maps keys onto servers; Ketama by default.
+	Algorithm Algorithm
+	// PoolOptions configure the pool of every server.
+	PoolOptions []tcppool.Option
+	// EjectAfter is the number of consecutive failures (dial errors or broken connections)
+	// after which a server is ejected and its keys rehashed. 0 disables ejection.
+	EjectAfter int
+	// RetryAfter is how long an ejected server stays out before it is tried again;
+	// DefaultRetryAfter if zero.
+	RetryAfter time.Duration
+	// OnEject is called when a server is ejected, with the error that caused it.
+	OnEject func(address string, err error)
+	// OnRestore is called when an ejected server is put back into the distribution.
+	OnRestore func(address string)
+}
+
+// ServerStats is a snapshot of one server of a Client.
+type ServerStats struct {
+	Address      string        // Address of the server
+	Weight       int           // Weight of the server
+	Ejected      bool          // Whether the server is currently ejected
+	EjectedUntil time.Time     // When an ejected server will be retried
+	Failures     int           // Consecutive failures so far
+	Pool         tcppool.Stats // Stats of the server's pool
+}
+
+// Stats is a snapshot of a Client.
+type Stats struct {
+	Servers   []ServerStats // Every server, in configuration order
+	Ejections uint64        // Number of times a server was ejected
+}
+
+// node is a server with its pool and health.
+type node struct {
+	server       Server
+	pool         *tcppool.Pool
+	failures     atomic.Int32
+	ejectedUntil atomic.Int64 // Unix nanoseconds; 0 while the server is live
+}
+
+// picker maps a key onto one of the live servers.
+type picker interface {
+	pick(key string) *node
+}
+
+// distribution is the picker over the live servers and when the next ejected one is due back.
+type distribution struct {
+	picker  picker
+	restore int64 // Unix nanoseconds of the earliest retry, or 0 if no server is ejected
+}
+
+// Client routes requests to the pool of the server owning each key.
+type Client struct {
+	cfg   Config
+	nodes []*node
+
+	mu        sync.Mutex // Serializes ejections and restores
+	dist      atomic.Pointer[distribution]
+	owners    sync.Map // Checked-out connection -> *node
+	ejections atomic.Uint64
+}
+
+// New creates a Client with one pool per server.
+//
+// Parameters:
+//   - servers: The servers to distribute keys over; addresses must be unique.
+//   - cfg: The client configuration.
+//
+// Returns:
+//   - A pointer to the created Client.
+//   - An error, if the configuration is invalid or a pool cannot be created.
+func New(servers []Server, cfg Config) (*Client, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("shard: at least one server is required")
+	}
+	switch cfg.Algorithm {
+	case "":
+		cfg.Algorithm = Ketama
+	case Ketama, Rendezvous:
+	default:
+		return nil, fmt.Errorf("shard: unknown algorithm %q", cfg.Algorithm)
+	}
+	if cfg.EjectAfter < 0 {
+		return nil, fmt.Errorf("shard: EjectAfter must not be negative")
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = DefaultRetryAfter
+	}
+
+	c := &Client{cfg: cfg}
+	seen := make(map[string]bool)
+	for _, s := range servers {
+		if s.Address == "" || seen[s.Address] {
+			c.Close()
+			return nil, fmt.Errorf("shard: server addresses must be set and unique, got %q", s.Address)
+		}
+		if s.Weight < 0 {
+			c.Close()
+			return nil, fmt.Errorf("shard: server %s has a negative weight", s.Address)
+		}
+		if s.Weight == 0 {
+			s.Weight = 1
+		}
+		seen[s.Address] = true
+
+		pool, err := tcppool.New(s.Address, cfg.PoolOptions...)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("shard: creating pool for %s: %w", s.Address, err)
+		}
+		c.nodes = append(c.nodes, &node{server: s, pool: pool})
+	}
+	c.rebuild(time.Now())
+	return c, nil
+}
+
+// Lookup returns the address of the server currently owning key.
+//
+// Parameters:
+//   - key: The request key.
+//
+// Returns:
+//   - The server address.
+//   - ErrNoServers, if every server is ejected.
+func (c *Client) Lookup(key string) (string, error) {
+	n, err := c.pick(key)
+	if err != nil {
+		return "", err
+	}
+	return n.server.Address, nil
+}
+
+// Get retrieves a connection to the server owning key.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - key: The request key.
+//
+// Returns:
+//   - A net.Conn representing the connection. Hand it back with Client.Release.
+//   - An error, if every server is ejected or the connection retrieval fails.
+func (c *Client) Get(ctx context.Context, key string) (net.Conn, error) {
+	n, err := c.pick(key)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := n.pool.GetContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil && serverFailed(err) {
+			c.fail(n, err)
+		}
+		return nil, err
+	}
+	n.failures.Store(0)
+	c.owners.Store(conn, n)
+	return conn, nil
+}
+
+// Release returns a connection obtained from Get to its server's pool.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//
+// Returns:
+//   - An error, if conn was not acquired from this client or the release fails.
+func (c *Client) Release(conn net.Conn) error {
+	return c.ReleaseWithError(conn, nil)
+}
+
+// ReleaseWithError returns a connection after the caller observed err on it. A broken
+// connection is discarded and counts as a failure of its server.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//   - err: The error observed while using the connection, or nil.
+//
+// Returns:
+//   - An error, if conn was not acquired from this client or the release fails.
+func (c *Client) ReleaseWithError(conn net.Conn, err error) error {
+	value, ok := c.owners.LoadAndDelete(conn)
+	if !ok {
+		return errUnknownConn
+	}
+	n := value.(*node)
+	if internal.IsBrokenConn(err) {
+		c.fail(n, err)
+	}
+	return n.pool.ReleaseWithError(conn, err)
+}
+
+// Discard closes a connection obtained from Get instead of returning it to its pool.
+//
+// Parameters:
+//   - conn: The connection, exactly as returned by Get.
+//
+// Returns:
+//   - An error, if conn was not acquired from this client or closing fails.
+func (c *Client) Discard(conn net.Conn) error {
+	value, ok := c.owners.LoadAndDelete(conn)
+	if !ok {
+		return errUnknownConn
+	}
+	return value.(*node).pool.Discard(conn)
+}
+
+// Do runs fn with a connection to the server owning key, as tcppool.Pool.Do does.
+// A dial error or a broken connection counts as a failure of the server.
+//
+// Parameters:
+//   - ctx: The context bounding the acquisition.
+//   - key: The request key.
+//   - fn: The callback using the connection.
+//
+// Returns:
+//   - The error returned by fn, or the acquisition error.
+func (c *Client) Do(ctx context.Context, key string, fn func(net.Conn) error) error {
+	n, err := c.pick(key)
+	if err != nil {
+		return err
+	}
+	err = n.pool.Do(ctx, fn)
+	switch {
+	case err == nil:
+		n.failures.Store(0)
+	case ctx.Err() == nil && serverFailed(err):
+		c.fail(n, err)
+	}
+	return err
+}
+
+// Stats returns a snapshot of every server and its pool.
+//
+// Returns:
+//   - A Stats value.
+func (c *Client) Stats() Stats {
+	s := Stats{Ejections: c.ejections.Load()}
+	for _, n := range c.nodes {
+		ss := ServerStats{
+			Address:  n.server.Address,
+			Weight:   n.server.Weight,
+			Failures: int(n.failures.Load()),
+			Pool:     n.pool.Stats(),
+		}
+		if until := n.ejectedUntil.Load(); until != 0 {
+			ss.Ejected = true
+			ss.EjectedUntil = time.Unix(0, until)
+		}
+		s.Servers = append(s.Servers, ss)
+	}
+	return s
+}
+
+// Close closes the pools of every server.
+//
+// Returns:
+//   - The errors returned while closing the pools, joined.
+func (c *Client) Close() error {
+	var errs []error
+	for _, n := range c.nodes {
+		errs = append(errs, n.pool.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// pick returns the live server owning key, first restoring ejected servers whose retry is due.
+func (c *Client) pick(key string) (*node, error) {
+	d := c.dist.Load()
+	if d.restore != 0 && time.Now().UnixNano() >= d.restore {
+		c.mu.Lock()
+		d = c.rebuild(time.Now())
+		c.mu.Unlock()
+	}
+	if n := d.picker.pick(key); n != nil {
+		return n, nil
+	}
+	return nil, ErrNoServers
+}
+
+// serverFailed reports whether err is a failure of the server itself: a dial error or a broken
+// connection. Rejections by hooks, quotas or the health check say nothing about the server.
+func serverFailed(err error) bool {
+	return errors.Is(err, tcppool.ErrDialFailed) || internal.IsBrokenConn(err)
+}
+
+// fail records a failure of n and ejects it once EjectAfter consecutive failures are reached.
+func (c *Client) fail(n *node, err error) {
+	if c.cfg.EjectAfter == 0 || int(n.failures.Add(1)) < c.cfg.EjectAfter {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n.ejectedUntil.Load() != 0 {
+		return
+	}
+	n.ejectedUntil.Store(time.Now().Add(c.cfg.RetryAfter).UnixNano())
+	c.ejections.Add(1)
+	c.rebuild(time.Now())
+
+	if c.cfg.OnEject != nil {
+		c.cfg.OnEject(n.server.Address, err)
+	} else {
+		fmt.Printf("Ejecting server %s after %d failures: %v\n", n.server.Address, c.cfg.EjectAfter, err)
+	}
+}
+
+// rebuild restores the ejected servers whose retry is due and publishes a new distribution
+// over the live servers. It must be called with c.mu held, except from New.
+//
+// Parameters:
+//   - now: The current time.
+//
+// Returns:
+//   - The new distribution.
+func (c *Client) rebuild(now time.Time) *distribution {
+	d := &distribution{}
+	var live []*node
+	for _, n := range c.nodes {
+		until := n.ejectedUntil.Load()
+		if until != 0 && now.UnixNano() >= until {
+			n.ejectedUntil.Store(0)
+			n.failures.Store(0)
+			until = 0
+			if c.cfg.OnRestore != nil {
+				c.cfg.OnRestore(n.server.Address)
+			} else {
+				fmt.Printf("Restoring server %s\n", n.server.Address)
+			}
+		}
+		if until != 0 {
+			if d.restore == 0 || until < d.restore {
+				d.restore = until
+			}
+			continue
+		}
+		live = append(live, n)
+	}
+
+	if c.cfg.Algorithm == Rendezvous {
+		d.picker = newRendezvous(live)
+	} else {
+		d.picker = newKetama(live)
+	}
+	c.dist.Store(d)
+	return d
+}
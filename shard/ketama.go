@@ -0,0 +1,75 @@
+package shard
+
+import (
+	"cmp"
+	"crypto/md5"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+// pointsPerHash is the number of ring points taken from each MD5 digest.
+const pointsPerHash = 4
+
+// hashesPerServer is the number of digests computed per server when all weights are equal,
+// giving the customary 160 points per server.
+const hashesPerServer = 40
+
+// ketama is a consistent hash ring laid out like libketama and libmemcached's weighted ketama
+// distribution, so that keys map to the same servers as in other ketama clients given the same
+// server list.
+type ketama struct {
+	points []ketamaPoint
+}
+
+// ketamaPoint is a position on the ring and the server owning it.
+type ketamaPoint struct {
+	hash uint32
+	node *node
+}
+
+// newKetama places the servers on a ring. Each server gets floor(40 * n * weight / totalWeight)
+// MD5 digests of "address-i", and every digest yields four points.
+//
+// Parameters:
+//   - nodes: The servers to place, with positive weights.
+//
+// Returns:
+//   - The ring.
+func newKetama(nodes []*node) *ketama {
+	total := 0
+	for _, n := range nodes {
+		total += n.server.Weight
+	}
+	k := &ketama{}
+	for _, n := range nodes {
+		hashes := hashesPerServer * len(nodes) * n.server.Weight / total
+		for i := 0; i < hashes; i++ {
+			digest := md5.Sum([]byte(n.server.Address + "-" + strconv.Itoa(i)))
+			for h := 0; h < pointsPerHash; h++ {
+				hash := binary.LittleEndian.Uint32(digest[h*4:])
+				k.points = append(k.points, ketamaPoint{hash: hash, node: n})
+			}
+		}
+	}
+	slices.SortStableFunc(k.points, func(a, b ketamaPoint) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+	return k
+}
+
+// pick returns the server owning the first point at or after the key's hash, wrapping around.
+func (k *ketama) pick(key string) *node {
+	if len(k.points) == 0 {
+		return nil
+	}
+	digest := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(digest[:4])
+	i, _ := slices.BinarySearchFunc(k.points, hash, func(p ketamaPoint, h uint32) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(k.points) {
+		i = 0
+	}
+	return k.points[i].node
+}
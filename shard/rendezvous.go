@@ -0,0 +1,62 @@
+package shard
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// rendezvous picks, for every key, the server with the highest weighted score
+// -weight/ln(u), where u is a uniform hash of the key and the server. Removing a server only
+// moves the keys it owned, and each server owns a share of the keys proportional to its weight.
+type rendezvous struct {
+	nodes []*node
+	seeds []uint64
+}
+
+// newRendezvous prepares rendezvous hashing over the servers.
+//
+// Parameters:
+//   - nodes: The candidate servers, with positive weights.
+//
+// Returns:
+//   - The picker.
+func newRendezvous(nodes []*node) *rendezvous {
+	r := &rendezvous{nodes: nodes, seeds: make([]uint64, len(nodes))}
+	for i, n := range nodes {
+		r.seeds[i] = hash64(n.server.Address)
+	}
+	return r
+}
+
+// pick returns the server with the highest score for key.
+func (r *rendezvous) pick(key string) *node {
+	var best *node
+	bestScore := math.Inf(-1)
+	h := hash64(key)
+	for i, n := range r.nodes {
+		// Map the mixed hash to a uniform u in (0, 1).
+		u := (float64(mix64(h^r.seeds[i])>>11) + 0.5) / (1 << 53)
+		score := -float64(n.server.Weight) / math.Log(u)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// hash64 returns the 64-bit FNV-1a hash of s.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix64 is the SplitMix64 finalizer, spreading the bits of x evenly.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
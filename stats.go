@@ -0,0 +1,22 @@
+package tcppool
+
+import "github.com/meliadamian17/tcppool/internal"
+
+// Stats is a point-in-time snapshot of a pool's connection counts and lifetime counters.
+type Stats = internal.Stats
+
+// DiscardReason explains why a connection was discarded instead of being reused.
+type DiscardReason = internal.DiscardReason
+
+const (
+	// DiscardExplicit means the caller discarded the connection with Discard.
+	DiscardExplicit = internal.DiscardExplicit
+	// DiscardError means the caller reported an error that broke the stream.
+	DiscardError = internal.DiscardError
+	// DiscardPanic means a callback panicked while using the connection.
+	DiscardPanic = internal.DiscardPanic
+	// DiscardDirty means the connection was released with unread data pending.
+	DiscardDirty = internal.DiscardDirty
+	// DiscardRejected means an OnBorrow or OnReturn hook rejected the connection.
+	DiscardRejected = internal.DiscardRejected
+)
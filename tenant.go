@@ -0,0 +1,51 @@
+package tcppool
+
+import (
+	"context"
+
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// TenantQuota bounds the connections a tenant borrows from a pool at once. Max caps the tenant's
+// concurrent acquisitions: beyond it, acquisitions fail with ErrQuotaExceeded. Min keeps that many
+// connections available for the tenant: other callers wait rather than take them while the tenant
+// borrows fewer.
+type TenantQuota = internal.TenantQuota
+
+// TenantStats reports a tenant's use of a pool. Stats.Tenants holds one per tenant.
+type TenantStats = internal.TenantStats
+
+// ContextWithTenant returns a copy of ctx carrying a tenant ID. Pass it to GetContext, GetForKey,
+// Do or GetAsync to acquire connections on behalf of that tenant.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - tenant: The tenant ID.
+//
+// Returns:
+//   - The derived context.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return internal.ContextWithTenant(ctx, tenant)
+}
+
+// SetTenantQuota sets the quota of a tenant while the pool is in use. It applies to the tenant's
+// next acquisitions; connections it already borrows are kept.
+//
+// Parameters:
+//   - tenant: The tenant ID.
+//   - quota: The tenant's quota.
+//
+// Returns:
+//   - An error, if the quota is invalid or the tenants' guaranteed minimums would add up to more
+//     than MaxConnections minus ReservedConnections.
+func (p *Pool) SetTenantQuota(tenant string, quota TenantQuota) error {
+	return p.impl.SetTenantQuota(tenant, quota)
+}
+
+// RemoveTenantQuota makes a tenant fall back to the default tenant quota.
+//
+// Parameters:
+//   - tenant: The tenant ID.
+func (p *Pool) RemoveTenantQuota(tenant string) {
+	p.impl.RemoveTenantQuota(tenant)
+}
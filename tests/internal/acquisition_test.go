@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestAcquisitionResult(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+
+	a := pool.GetAsync(context.Background())
+	conn, err := a.Result()
+	utils.AssertNil(t, err, "Acquisition should succeed")
+	utils.AssertNotNil(t, conn, "Acquisition should yield a connection")
+
+	a.Cancel()
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Cancel after Result should not reclaim the connection")
+	pool.Release(conn)
+}
+
+func TestAcquisitionCancelReturnsConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+
+	a := pool.GetAsync(context.Background())
+	<-a.Done()
+	a.Cancel()
+
+	conn, err := a.Result()
+	utils.AssertNil(t, conn, "Cancelled acquisition should not yield a connection")
+	utils.AssertTrue(t, errors.Is(err, context.Canceled), "Cancelled acquisition should report context.Canceled")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Abandoned connection should be returned to the pool")
+}
+
+func TestAcquisitionContextCancelled(t *testing.T) {
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = "localhost:1"
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := pool.GetAsync(ctx)
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Acquisition should complete once its context is cancelled")
+	}
+
+	_, err := a.Result()
+	utils.AssertTrue(t, errors.Is(err, context.Canceled), "Acquisition should report context.Canceled")
+}
+
+func TestAcquisitionContextDeadline(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	utils.AssertNil(t, pool.Resize(1), "Resize should succeed")
+	held, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	defer pool.Release(held)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetAsync(ctx).Result()
+	utils.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "Acquisition should report the parent's deadline")
+}
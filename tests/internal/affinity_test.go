@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestGetForKeyPrefersKeyedConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+	})
+	ctx := context.Background()
+	alice, _ := pool.GetForKey(ctx, "alice")
+	bob, _ := pool.GetForKey(ctx, "bob")
+	aliceID := alice.(*internal.PooledConn).Info().ID
+	bobID := bob.(*internal.PooledConn).Info().ID
+	utils.AssertNil(t, pool.Release(alice), "Release should succeed")
+	utils.AssertNil(t, pool.Release(bob), "Release should succeed")
+
+	// LIFO would hand out bob's connection first.
+	conn, err := pool.GetForKey(ctx, "alice")
+	utils.AssertNil(t, err, "GetForKey should succeed")
+	utils.AssertEqual(t, aliceID, conn.(*internal.PooledConn).Info().ID, "The connection last used with the key should be preferred")
+	utils.AssertNil(t, pool.Release(conn), "Release should succeed")
+
+	stats := pool.Stats()
+	utils.AssertEqual(t, uint64(1), stats.KeyHits, "Reusing a keyed connection should count as a hit")
+	utils.AssertEqual(t, uint64(2), stats.KeyMisses, "Dialing for a new key should count as a miss")
+	utils.AssertEqual(t, aliceID, stats.Keys["alice"][0], "Stats should map keys to connections")
+	utils.AssertEqual(t, bobID, stats.Keys["bob"][0], "Stats should map keys to connections")
+}
+
+func TestGetForKeyFallsBackToAnyConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+	})
+	ctx := context.Background()
+	conn, _ := pool.GetForKey(ctx, "alice")
+	utils.AssertNil(t, pool.Release(conn), "Release should succeed")
+
+	conn, err := pool.GetForKey(ctx, "carol")
+	utils.AssertNil(t, err, "GetForKey should succeed")
+	utils.AssertEqual(t, "carol", conn.(*internal.PooledConn).Info().Key, "The connection should now be keyed to carol")
+	utils.AssertEqual(t, 1, pool.Stats().Open, "An idle connection with another key should be reused before dialing")
+	utils.AssertEqual(t, 0, len(pool.Stats().Keys["alice"]), "The previous key should no longer map to the connection")
+	pool.Release(conn)
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+// hammer keeps callers borrowing connections, each held for hold, for the given duration.
+func hammer(t *testing.T, pool *internal.ConnectionPool, callers int, hold, duration time.Duration) {
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				conn, err := pool.Get()
+				if err != nil {
+					t.Errorf("Get failed: %v", err)
+					return
+				}
+				time.Sleep(hold)
+				pool.Release(conn)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAutoscaleGrowsWhenCallersQueue(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 1
+		c.Autoscale = &internal.AutoscaleConfig{
+			Min:              1,
+			Max:              4,
+			Algorithm:        internal.ScaleAIMD,
+			Interval:         50 * time.Millisecond,
+			LatencyThreshold: time.Second,
+		}
+	})
+	defer pool.Close()
+
+	hammer(t, pool, 6, 5*time.Millisecond, 600*time.Millisecond)
+
+	stats := pool.Stats().Autoscale
+	utils.AssertNotNil(t, stats, "Stats should report the autoscaler")
+	utils.AssertEqual(t, 4, stats.Limit, "The limit should grow to the maximum while callers queue")
+	utils.AssertEqual(t, 4, pool.MaxConnections, "MaxConnections should follow the limit")
+	utils.AssertTrue(t, stats.Increases >= 3, "Stats should count the increases")
+}
+
+func TestAutoscaleGrowsSaturatedPoolWithoutReleases(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+		c.Autoscale = &internal.AutoscaleConfig{
+			Min:              2,
+			Max:              8,
+			Algorithm:        internal.ScaleAIMD,
+			Interval:         50 * time.Millisecond,
+			LatencyThreshold: time.Second,
+		}
+	})
+	defer pool.Close()
+
+	// Four callers each hold a connection for 400ms, far beyond the interval: nothing is released
+	// while the first two are served and the other two queue.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hammer(t, pool, 4, 400*time.Millisecond, 300*time.Millisecond)
+	}()
+	time.Sleep(250 * time.Millisecond)
+
+	stats := pool.Stats()
+	utils.AssertEqual(t, uint64(0), stats.Released, "No connection should have been released yet")
+	utils.AssertEqual(t, 4, stats.Autoscale.Limit, "The limit should grow until no caller queues")
+	utils.AssertEqual(t, 4, stats.InUse, "Every caller should hold a connection")
+	utils.AssertEqual(t, 0.0, stats.Autoscale.Queued, "No caller should queue once the limit grew")
+	<-done
+}
+
+func TestAutoscaleShrinksWhenLatencyRises(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 8
+		c.Autoscale = &internal.AutoscaleConfig{
+			Min:              2,
+			Max:              8,
+			Algorithm:        internal.ScaleAIMD,
+			Interval:         50 * time.Millisecond,
+			LatencyThreshold: 20 * time.Millisecond,
+			Decrease:         0.5,
+		}
+	})
+	defer pool.Close()
+	events := pool.Subscribe(internal.EventTypes(internal.EventLimitChange), internal.SubscribeOptions{})
+
+	hammer(t, pool, 2, 40*time.Millisecond, 500*time.Millisecond)
+
+	stats := pool.Stats().Autoscale
+	utils.AssertEqual(t, 2, stats.Limit, "The limit should shrink to the minimum while the backend is slow")
+	utils.AssertTrue(t, stats.Decreases >= 2, "Stats should count the decreases")
+	utils.AssertTrue(t, stats.Latency >= 20*time.Millisecond, "Stats should report the latency")
+
+	select {
+	case e := <-events:
+		utils.AssertEqual(t, 4, e.Limit, "The first change should halve the limit")
+	case <-time.After(time.Second):
+		t.Fatal("A limit change event should be published")
+	}
+}
+
+func TestAutoscaleGradientBacksOffFromBaseline(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 8
+		c.Autoscale = &internal.AutoscaleConfig{
+			Min:       2,
+			Max:       8,
+			Algorithm: internal.ScaleGradient,
+			Interval:  50 * time.Millisecond,
+		}
+	})
+	defer pool.Close()
+
+	hammer(t, pool, 2, 2*time.Millisecond, 200*time.Millisecond)
+	utils.AssertEqual(t, 8, pool.Stats().Autoscale.Limit, "The limit should hold while the latency stays at the baseline")
+
+	hammer(t, pool, 2, 50*time.Millisecond, 600*time.Millisecond)
+	stats := pool.Stats().Autoscale
+	utils.AssertTrue(t, stats.Limit < 8, "The limit should shrink once the latency exceeds the tolerated baseline")
+	utils.AssertTrue(t, stats.Baseline < stats.Latency/2, "The baseline should only drift slowly towards the higher latency")
+}
+
+func TestAutoscaleConfigValidation(t *testing.T) {
+	valid := internal.AutoscaleConfig{Min: 2, Max: 8, Algorithm: internal.ScaleGradient}
+	tests := map[string]func(c *internal.ConfigImpl){
+		"min above max":         func(c *internal.ConfigImpl) { c.Autoscale.Min = 10 },
+		"min not above reserve": func(c *internal.ConfigImpl) { c.ReservedConnections = 2 },
+		"limit outside range":   func(c *internal.ConfigImpl) { c.MaxConnections = 9 },
+		"unknown algorithm":     func(c *internal.ConfigImpl) { c.Autoscale.Algorithm = "pid" },
+		"aimd without threshold": func(c *internal.ConfigImpl) {
+			c.Autoscale.Algorithm = internal.ScaleAIMD
+		},
+		"decrease out of range": func(c *internal.ConfigImpl) {
+			c.Autoscale.Algorithm, c.Autoscale.LatencyThreshold, c.Autoscale.Decrease = internal.ScaleAIMD, time.Second, 1.5
+		},
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			scale := valid
+			c := internal.ConfigImpl{
+				Address:        "localhost:1",
+				MaxConnections: 4,
+				IdleTimeout:    time.Second,
+				MaxRetries:     1,
+				Backoff:        &utils.MockBackoff{},
+				Autoscale:      &scale,
+			}
+			utils.AssertNil(t, c.Validate(), "The base configuration should be valid")
+			mutate(&c)
+			utils.AssertTrue(t, errors.Is(c.Validate(), internal.ErrInvalidConfig), "Validate should reject the configuration")
+		})
+	}
+}
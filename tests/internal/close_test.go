@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestClose(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+	})
+	first, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	second, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := pool.GetContext(context.Background())
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	utils.AssertNil(t, pool.Close(), "Close should succeed")
+	utils.AssertTrue(t, pool.Closed(), "Pool should report being closed")
+
+	select {
+	case err := <-waiter:
+		utils.AssertTrue(t, errors.Is(err, internal.ErrPoolClosed), "A waiting Get should fail once the pool is closed")
+	case <-time.After(time.Second):
+		t.Fatal("Waiting Get was not woken by Close")
+	}
+
+	utils.AssertNil(t, pool.Release(first), "Releasing to a closed pool should succeed")
+	utils.AssertNil(t, pool.Release(second), "Releasing to a closed pool should succeed")
+	utils.AssertEqual(t, 0, pool.Stats().Open, "Released connections should be closed")
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Released connections should not be pooled")
+
+	_, err = pool.Get()
+	utils.AssertTrue(t, errors.Is(err, internal.ErrPoolClosed), "Get on a closed pool should fail")
+	utils.AssertNil(t, pool.Close(), "Closing twice should do nothing")
+}
+
+func TestCloseClosesIdleConnections(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+	})
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	utils.AssertNil(t, pool.Release(conn), "Release should succeed")
+
+	utils.AssertNil(t, pool.Close(), "Close should succeed")
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Idle connections should be closed")
+	utils.AssertEqual(t, 0, pool.Stats().Open, "No connection should be left open")
+}
@@ -9,43 +9,103 @@ import (
 	"github.com/meliadamian17/tcppool/tests/utils"
 )
 
-func TestValidateConnection_ValidConnection(t *testing.T) {
+func TestIdleConnectionsExpireIndividually(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 50 * time.Millisecond})
+	defer server.Stop()
 
-	serverConfig := utils.MockServerConfig{
-		SendData:     true,
-		Data:         []byte("test data"),
-		SendInterval: 250 * time.Millisecond,
-	}
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+		c.IdleTimeout = 400 * time.Millisecond
+	})
+	first, _ := pool.Get()
+	second, _ := pool.Get()
+
+	utils.AssertNil(t, pool.Release(first), "Release should succeed")
+	time.Sleep(250 * time.Millisecond)
+	utils.AssertNil(t, pool.Release(second), "Release should succeed")
+
+	time.Sleep(300 * time.Millisecond)
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Only the connection past its idle deadline should be closed")
+
+	time.Sleep(400 * time.Millisecond)
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "The second connection should expire at its own deadline")
+}
 
-	server, address := utils.NewMockServer(t, serverConfig)
+func TestValidationKeepsQuietConnections(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
 	defer server.Stop()
 
-	conn, err := net.Dial("tcp", address)
-	if err != nil {
-		t.Fatalf("Failed to connect to mock server: %v", err)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+		c.IdleTimeout = 600 * time.Millisecond
+		c.ValidateConcurrency = 2
+	})
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed")
+		conns = append(conns, conn)
+	}
+	time.Sleep(400 * time.Millisecond)
+	for _, conn := range conns {
+		utils.AssertNil(t, pool.Release(conn), "Release should succeed")
 	}
-	defer conn.Close()
 
-	utils.AssertTrue(t, internal.Validate(conn), "Connection should be valid")
+	// The check runs at 600ms; the mock server sends nothing, which does not make a connection invalid.
+	time.Sleep(400 * time.Millisecond)
+	utils.AssertEqual(t, 3, pool.Stats().Idle, "Quiet connections should pass validation and stay available")
 
-	conn.Close()
-	utils.AssertFalse(t, internal.Validate(conn), "Connection should not be valid after close")
+	// They expire at their own deadline, 1s.
+	time.Sleep(400 * time.Millisecond)
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Quiet connections should expire at their idle deadline")
 }
 
-func TestValidateConnection_InvalidConnection(t *testing.T) {
+func TestValidationClosesDeadConnections(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
 
-	serverConfig := utils.MockServerConfig{
-		SendData: false,
-	}
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+		c.IdleTimeout = 600 * time.Millisecond
+		c.ValidateConcurrency = 1
+	})
+	first, _ := pool.Get()
+	second, _ := pool.Get()
+	time.Sleep(400 * time.Millisecond)
+	pool.Release(first)
+	pool.Release(second)
+	server.Stop()
+
+	// The check at 600ms finds the peer gone, well before the 1s idle deadline.
+	time.Sleep(400 * time.Millisecond)
+	stats := pool.Stats()
+	utils.AssertEqual(t, 0, stats.Idle, "Connections closed by the peer should be removed")
+	utils.AssertEqual(t, 0, stats.Open, "Connections closed by the peer should be closed")
+}
 
-	server, address := utils.NewMockServer(t, serverConfig)
+func TestGetReusesQuietIdleConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
 	defer server.Stop()
 
-	conn, err := net.Dial("tcp", address)
-	if err != nil {
-		t.Fatalf("Failed to connect to mock server: %v", err)
-	}
-	defer conn.Close()
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	first, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	local := first.LocalAddr().String()
+	utils.AssertNil(t, pool.Release(first), "Release should succeed")
 
-	utils.AssertFalse(t, internal.Validate(conn), "Conn Should Be Invalid")
+	// The server sends nothing: the idle check must not wait for data before handing it out.
+	start := time.Now()
+	second, err := pool.Get()
+	elapsed := time.Since(start)
+	utils.AssertNil(t, err, "Get should succeed")
+	defer pool.Release(second)
+	utils.AssertEqual(t, local, second.LocalAddr().String(), "The idle connection should be reused")
+	utils.AssertEqual(t, uint64(1), pool.Stats().Created, "No new connection should be dialed")
+	if elapsed > 100*time.Millisecond {
+		t.Errorf("Get took %v, expected the idle connection without a stall", elapsed)
+	}
 }
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestDirtyConnectionDiscarded(t *testing.T) {
+	serverConfig := utils.MockServerConfig{
+		SendData:     true,
+		Data:         []byte("unread response"),
+		SendInterval: 20 * time.Millisecond,
+	}
+	server, address := utils.NewMockServer(t, serverConfig)
+	defer server.Stop()
+
+	discards := make(chan error, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.CheckDirtyOnRelease = true
+		c.Hooks.OnConnectionDiscard = func(conn net.Conn, reason internal.DiscardReason, err error) {
+			discards <- err
+		}
+	})
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	pool.Release(conn)
+
+	select {
+	case err := <-discards:
+		var dirty *internal.DirtyConnError
+		utils.AssertTrue(t, errors.As(err, &dirty), "Discard should report a DirtyConnError")
+		utils.AssertTrue(t, dirty.Pending > 0, "Pending byte count should be reported")
+	default:
+		t.Fatal("Dirty connection should have been discarded")
+	}
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Dirty connection should not be pooled")
+	utils.AssertEqual(t, uint64(1), pool.Stats().Discarded[internal.DiscardDirty], "Dirty discard should be counted")
+}
+
+func TestCleanConnectionReleased(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	discards := make(chan error, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.CheckDirtyOnRelease = true
+		c.Hooks.OnConnectionDiscard = func(conn net.Conn, reason internal.DiscardReason, err error) {
+			discards <- err
+		}
+	})
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(-time.Second))
+	pool.Release(conn)
+
+	utils.AssertEqual(t, 0, len(discards), "Clean connection should not be discarded")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Clean connection should be pooled")
+}
+
+func TestDirtyTLSConnectionDiscarded(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{
+		SendData:     true,
+		Data:         []byte("unread response"),
+		SendInterval: 100 * time.Millisecond,
+		TLS:          true,
+	})
+	defer server.Stop()
+
+	discards := make(chan error, 2)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+		c.CheckDirtyOnRelease = true
+		c.Hooks.OnConnectionDiscard = func(conn net.Conn, reason internal.DiscardReason, err error) {
+			discards <- err
+		}
+	})
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	_, ok := conn.(*internal.PooledConn).Conn.(*tls.Conn)
+	utils.AssertTrue(t, ok, "The connection should use TLS")
+	pool.Release(conn)
+	utils.AssertEqual(t, 0, len(discards), "A TLS connection without pending data should not be discarded")
+
+	conn, err = pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	time.Sleep(250 * time.Millisecond)
+	pool.Release(conn)
+
+	select {
+	case err := <-discards:
+		var dirty *internal.DirtyConnError
+		utils.AssertTrue(t, errors.As(err, &dirty), "Discard should report a DirtyConnError")
+	default:
+		t.Fatal("A TLS connection with a pending record should have been discarded")
+	}
+}
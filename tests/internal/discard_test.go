@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestReleaseWithError(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	var reasons []internal.DiscardReason
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks.OnConnectionDiscard = func(conn net.Conn, reason internal.DiscardReason, err error) {
+			reasons = append(reasons, reason)
+		}
+	})
+
+	broken, _ := pool.Get()
+	healthy, _ := pool.Get()
+	explicit, _ := pool.Get()
+
+	utils.AssertNil(t, pool.ReleaseWithError(broken, io.EOF), "Releasing a broken connection should not fail")
+	utils.AssertNil(t, pool.ReleaseWithError(healthy, errors.New("bad request")), "Releasing after an application error should not fail")
+	utils.AssertNil(t, pool.Discard(explicit), "Discarding should not fail")
+
+	utils.AssertEqual(t, []internal.DiscardReason{internal.DiscardError, internal.DiscardExplicit}, reasons, "Discard hook should report each reason")
+
+	stats := pool.Stats()
+	utils.AssertEqual(t, 1, stats.Open, "Only the healthy connection should stay open")
+	utils.AssertEqual(t, 1, stats.Idle, "Healthy connection should be idle")
+	utils.AssertEqual(t, 0, stats.InUse, "No connection should be in use")
+	utils.AssertEqual(t, uint64(3), stats.Created, "Three connections should have been created")
+	utils.AssertEqual(t, uint64(2), stats.Closed, "Two connections should have been closed")
+	utils.AssertEqual(t, uint64(1), stats.Discarded[internal.DiscardError], "One connection should be discarded for an error")
+	utils.AssertEqual(t, uint64(1), stats.Discarded[internal.DiscardExplicit], "One connection should be discarded explicitly")
+}
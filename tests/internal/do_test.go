@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestDoReleasesOnSuccess(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	err := pool.Do(context.Background(), func(conn net.Conn) error {
+		return nil
+	})
+
+	utils.AssertNil(t, err, "Do should succeed")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Connection should be released after success")
+}
+
+func TestDoReleasesOnApplicationError(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	appErr := errors.New("key not found")
+	err := pool.Do(context.Background(), func(conn net.Conn) error {
+		return appErr
+	})
+
+	utils.AssertEqual(t, appErr, err, "Do should return the callback error")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Connection should be released after an application error")
+}
+
+func TestDoDiscardsOnNetworkError(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	err := pool.Do(context.Background(), func(conn net.Conn) error {
+		return &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNREFUSED}
+	})
+
+	utils.AssertNotNil(t, err, "Do should return the network error")
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Connection should be discarded after a network error")
+}
+
+func TestDoDiscardsOnPanic(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+	})
+	func() {
+		defer func() {
+			utils.AssertEqual(t, "boom", recover(), "Do should re-raise the panic")
+		}()
+		pool.Do(context.Background(), func(conn net.Conn) error {
+			panic("boom")
+		})
+	}()
+
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Connection should be discarded after a panic")
+}
+
+func TestDoRetriesStaleConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.DoRetries = 2
+	})
+	calls := 0
+	err := pool.Do(context.Background(), func(conn net.Conn) error {
+		calls++
+		if calls == 1 {
+			return &net.OpError{Op: "write", Net: "tcp", Err: syscall.ECONNRESET}
+		}
+		return nil
+	})
+
+	utils.AssertNil(t, err, "Do should succeed on the retry")
+	utils.AssertEqual(t, 2, calls, "Callback should run twice")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Only the healthy connection should be pooled")
+}
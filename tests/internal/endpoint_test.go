@@ -0,0 +1,342 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+// deadAddress returns an address nothing listens on.
+func deadAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+// useEndpoints borrows every connection of the pool rounds times, releasing those to the endpoints
+// in failing with an error.
+func useEndpoints(t *testing.T, pool *internal.ConnectionPool, rounds int, failing map[string]bool) {
+	for range rounds {
+		var conns []net.Conn
+		for range pool.MaxConnections {
+			conn, err := pool.Get()
+			utils.AssertNil(t, err, "Get should succeed")
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			if failing[conn.(*internal.PooledConn).Info().Endpoint] {
+				pool.ReleaseWithError(conn, errors.New("unexpected reply"))
+			} else {
+				pool.Release(conn)
+			}
+		}
+	}
+}
+
+func TestEndpointsAreDialedInTurn(t *testing.T) {
+	serverA, addressA := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverA.Stop()
+	serverB, addressB := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverB.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = addressA
+		c.Endpoints = []string{addressB}
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+	})
+	defer pool.Close()
+
+	var conns []net.Conn
+	for range 4 {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed")
+		conns = append(conns, conn)
+	}
+	perEndpoint := make(map[string]int)
+	for _, info := range pool.Snapshot() {
+		perEndpoint[info.Endpoint]++
+	}
+	utils.AssertEqual(t, 2, perEndpoint[addressA], "Connections should be spread over the address")
+	utils.AssertEqual(t, 2, perEndpoint[addressB], "Connections should be spread over the endpoints")
+
+	for _, conn := range conns {
+		pool.Release(conn)
+	}
+	stats := pool.Stats().Endpoints
+	utils.AssertEqual(t, 2, len(stats), "Stats should report every endpoint")
+	utils.AssertEqual(t, uint64(4), stats[addressA].Requests, "Stats should count the dials and releases of each endpoint")
+}
+
+func TestDialFailsOverToTheNextEndpoint(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+	dead := deadAddress(t)
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = dead
+		c.Endpoints = []string{address}
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+	})
+	defer pool.Close()
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed on the second endpoint")
+	utils.AssertEqual(t, address, conn.(*internal.PooledConn).Info().Endpoint, "The connection should come from the live endpoint")
+	pool.Release(conn)
+	utils.AssertEqual(t, uint64(1), pool.Stats().Endpoints[dead].Failures, "The failed dial should count against its endpoint")
+}
+
+func TestFailingEndpointIsEjectedAndReturns(t *testing.T) {
+	serverA, addressA := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverA.Stop()
+	serverB, addressB := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverB.Stop()
+
+	ejected := make(chan internal.Event, 4)
+	returned := make(chan internal.Event, 4)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = addressA
+		c.Endpoints = []string{addressB}
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+		c.Hooks = internal.PoolHooks{
+			OnEndpointEject:  func(e internal.Event) { ejected <- e },
+			OnEndpointReturn: func(e internal.Event) { returned <- e },
+		}
+		c.OutlierDetection = &internal.OutlierConfig{
+			Interval:         50 * time.Millisecond,
+			MinRequests:      2,
+			FailureThreshold: 0.5,
+			BaseEjectionTime: 100 * time.Millisecond,
+		}
+	})
+	defer pool.Close()
+
+	useEndpoints(t, pool, 2, map[string]bool{addressB: true})
+
+	select {
+	case e := <-ejected:
+		utils.AssertEqual(t, internal.EventEndpointEject, e.Type, "The hook should get an ejection event")
+		utils.AssertEqual(t, addressB, e.Address, "The failing endpoint should be ejected")
+		utils.AssertEqual(t, 100*time.Millisecond, e.Duration, "The first ejection should last the base ejection time")
+		utils.AssertNotNil(t, e.Err, "The event should say why the endpoint was ejected")
+	case <-time.After(time.Second):
+		t.Fatal("The failing endpoint should be ejected")
+	}
+	stats := pool.Stats().Endpoints[addressB]
+	utils.AssertTrue(t, stats.Ejected, "Stats should report the ejection")
+	utils.AssertEqual(t, uint64(1), stats.Ejections, "Stats should count the ejection")
+
+	var conns []net.Conn
+	for range pool.MaxConnections {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed")
+		utils.AssertEqual(t, addressA, conn.(*internal.PooledConn).Info().Endpoint, "Connections should avoid the ejected endpoint")
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		pool.Release(conn)
+	}
+
+	select {
+	case e := <-returned:
+		utils.AssertEqual(t, addressB, e.Address, "The endpoint should return once its probe succeeds")
+	case <-time.After(time.Second):
+		t.Fatal("The ejected endpoint should return")
+	}
+	utils.AssertFalse(t, pool.Stats().Endpoints[addressB].Ejected, "Stats should report the return")
+}
+
+func TestEjectionsAreCapped(t *testing.T) {
+	serverA, addressA := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverA.Stop()
+	serverB, addressB := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer serverB.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = addressA
+		c.Endpoints = []string{addressB}
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+		c.Hooks = internal.PoolHooks{OnEndpointEject: func(internal.Event) {}}
+		c.OutlierDetection = &internal.OutlierConfig{
+			Interval:         50 * time.Millisecond,
+			MinRequests:      2,
+			FailureThreshold: 0.5,
+			BaseEjectionTime: time.Minute,
+		}
+	})
+	defer pool.Close()
+
+	useEndpoints(t, pool, 2, map[string]bool{addressA: true, addressB: true})
+	time.Sleep(200 * time.Millisecond)
+
+	ejected := 0
+	for _, stats := range pool.Stats().Endpoints {
+		if stats.Ejected {
+			ejected++
+		}
+	}
+	utils.AssertEqual(t, 1, ejected, "No more endpoints than the cap allows should be ejected")
+}
+
+// slowEndpointEjected holds one connection to each of n endpoints, keeps the last one 50ms longer
+// than the others, and reports whether outlier detection with cfg then ejects that endpoint.
+func slowEndpointEjected(t *testing.T, n int, cfg internal.OutlierConfig) bool {
+	var addresses []string
+	for range n {
+		server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+		defer server.Stop()
+		addresses = append(addresses, address)
+	}
+	slow := addresses[n-1]
+
+	ejected := make(chan internal.Event, n)
+	cfg.Interval = 200 * time.Millisecond
+	cfg.MinRequests = 1
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = addresses[0]
+		c.Endpoints = addresses[1:]
+		c.MaxConnections = n
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+		c.Hooks = internal.PoolHooks{OnEndpointEject: func(e internal.Event) { ejected <- e }}
+		c.OutlierDetection = &cfg
+	})
+	defer pool.Close()
+
+	var conns []net.Conn
+	for range n {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed")
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		if conn.(*internal.PooledConn).Info().Endpoint != slow {
+			pool.Release(conn)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	for _, conn := range conns {
+		if conn.(*internal.PooledConn).Info().Endpoint == slow {
+			pool.Release(conn)
+		}
+	}
+
+	select {
+	case e := <-ejected:
+		utils.AssertEqual(t, slow, e.Address, "Only the endpoint holding connections far longer should be ejected")
+		return true
+	case <-time.After(500 * time.Millisecond):
+		return false
+	}
+}
+
+func TestSlowEndpointIsEjectedAsOutlier(t *testing.T) {
+	utils.AssertTrue(t, slowEndpointEjected(t, 6, internal.OutlierConfig{}), "The slow endpoint should be ejected with the default stdev factor")
+}
+
+func TestStdevEjectionNeedsMinHosts(t *testing.T) {
+	// Among 3 endpoints the slow one stands sqrt(2) standard deviations out, beyond a factor of 1.
+	utils.AssertFalse(t, slowEndpointEjected(t, 3, internal.OutlierConfig{StdevFactor: 1}), "Fewer endpoints than MinHosts should not be judged by standard deviation")
+	utils.AssertTrue(t, slowEndpointEjected(t, 3, internal.OutlierConfig{StdevFactor: 1, MinHosts: 3}), "A lower MinHosts should allow the standard deviation test")
+}
+
+func TestEjectedEndpointsAreProbedTogether(t *testing.T) {
+	var addresses []string
+	for range 3 {
+		server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+		defer server.Stop()
+		addresses = append(addresses, address)
+	}
+
+	ejected := make(chan time.Time, 4)
+	returned := make(chan time.Time, 4)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = addresses[0]
+		c.Endpoints = addresses[1:]
+		c.MaxConnections = 3
+		c.ConnTimeout = time.Second
+		c.MaxRetries = 2
+		c.Hooks = internal.PoolHooks{
+			OnEndpointEject:  func(internal.Event) { ejected <- time.Now() },
+			OnEndpointReturn: func(internal.Event) { returned <- time.Now() },
+		}
+		c.HealthCheck = &internal.HealthCheckConfig{
+			Interval: time.Minute,
+			Timeout:  time.Second,
+			Probe: func(ctx context.Context, conn net.Conn) error {
+				time.Sleep(300 * time.Millisecond)
+				return nil
+			},
+		}
+		c.OutlierDetection = &internal.OutlierConfig{
+			Interval:           50 * time.Millisecond,
+			MinRequests:        2,
+			FailureThreshold:   0.5,
+			BaseEjectionTime:   100 * time.Millisecond,
+			MaxEjectionPercent: 100,
+		}
+	})
+	defer pool.Close()
+
+	useEndpoints(t, pool, 2, map[string]bool{addresses[1]: true, addresses[2]: true})
+
+	var first, last time.Time
+	for range 2 {
+		select {
+		case first = <-ejected:
+		case <-time.After(time.Second):
+			t.Fatal("Both failing endpoints should be ejected")
+		}
+	}
+	for range 2 {
+		select {
+		case last = <-returned:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Both ejected endpoints should return")
+		}
+	}
+	// The ejection lasts 100ms and detection runs every 50ms; two 300ms probes in turn would take 600ms.
+	if took := last.Sub(first); took > 550*time.Millisecond {
+		t.Errorf("The endpoints returned %v after their ejection, expected their 300ms probes to run together", took)
+	}
+}
+
+func TestEndpointConfigValidation(t *testing.T) {
+	base := internal.ConfigImpl{
+		Address:        "localhost:1",
+		MaxConnections: 4,
+		IdleTimeout:    time.Second,
+		MaxRetries:     1,
+		Backoff:        &utils.MockBackoff{},
+	}
+
+	c := base
+	c.Endpoints = []string{"localhost:1"}
+	utils.AssertTrue(t, errors.Is(c.Validate(), internal.ErrInvalidConfig), "Endpoints repeating the address should be rejected")
+
+	c = base
+	c.OutlierDetection = &internal.OutlierConfig{}
+	utils.AssertTrue(t, errors.Is(c.Validate(), internal.ErrInvalidConfig), "Outlier detection should require several endpoints")
+
+	c.Endpoints = []string{"localhost:2"}
+	utils.AssertNil(t, c.Validate(), "Outlier detection over two endpoints should be valid")
+	c.OutlierDetection.FailureThreshold = 2
+	utils.AssertTrue(t, errors.Is(c.Validate(), internal.ErrInvalidConfig), "A failure threshold above 1 should be rejected")
+}
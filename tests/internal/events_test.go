@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestSubscribeDeliversInOrder(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Name = "events"
+	})
+	events := pool.Subscribe(nil, internal.SubscribeOptions{})
+	defer pool.Unsubscribe(events)
+
+	conn, _ := pool.Get()
+	pool.Release(conn)
+
+	expected := []internal.EventType{
+		internal.EventDialAttempt,
+		internal.EventConnectionCreate,
+		internal.EventConnectionAcquire,
+		internal.EventConnectionRelease,
+	}
+	for _, typ := range expected {
+		e := <-events
+		utils.AssertEqual(t, typ, e.Type, "Events should arrive in order")
+		utils.AssertEqual(t, "events", e.Pool, "Events should carry the pool name")
+	}
+}
+
+func TestSubscribeFilterAndDrops(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Name = "events"
+	})
+	events := pool.Subscribe(internal.EventTypes(internal.EventConnectionAcquire), internal.SubscribeOptions{Buffer: 1})
+
+	for i := 0; i < 3; i++ {
+		conn, _ := pool.Get()
+		pool.Release(conn)
+	}
+
+	utils.AssertEqual(t, internal.EventConnectionAcquire, (<-events).Type, "Filter should only pass acquire events")
+	utils.AssertEqual(t, uint64(2), pool.Stats().EventsDropped, "Events beyond the buffer should be dropped")
+
+	pool.Unsubscribe(events)
+	_, open := <-events
+	utils.AssertFalse(t, open, "Unsubscribe should close the channel")
+}
+
+func TestAddHookUnsubscribe(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Name = "events"
+	})
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	calls := 0
+	wg.Add(1)
+	remove := pool.AddHook(internal.EventTypes(internal.EventConnectionCreate), internal.SubscribeOptions{Blocking: true}, func(e internal.Event) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		wg.Done()
+	})
+
+	conn, _ := pool.Get()
+	wg.Wait()
+	remove()
+
+	other, _ := pool.Get()
+	pool.Release(conn)
+	pool.Release(other)
+
+	mu.Lock()
+	defer mu.Unlock()
+	utils.AssertEqual(t, 1, calls, "Removed hook should not be called again")
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestHookPanicDiscardsConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	panics := make(chan *internal.HookPanicError, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks = internal.PoolHooks{
+			OnConnectionCreate: func(conn net.Conn) {
+				panic("metrics exploded")
+			},
+			OnHookPanic: func(err *internal.HookPanicError) {
+				panics <- err
+			},
+		}
+	})
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, conn, "No connection should be returned")
+	utils.AssertTrue(t, errors.Is(err, internal.ErrHookPanic), "Get should report the hook panic")
+
+	report := <-panics
+	utils.AssertEqual(t, "OnConnectionCreate", report.Hook, "Report should name the hook")
+	utils.AssertEqual(t, "metrics exploded", report.Value, "Report should carry the panic value")
+	utils.AssertEqual(t, 0, pool.Stats().Open, "Connection should be discarded")
+	utils.AssertEqual(t, uint64(1), pool.Stats().Discarded[internal.DiscardPanic], "Discard should be attributed to the panic")
+}
+
+func TestHookPanicInBackgroundCleanup(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	panics := make(chan *internal.HookPanicError, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.IdleTimeout = 100 * time.Millisecond
+		c.Hooks = internal.PoolHooks{
+			OnConnectionClose: func(conn net.Conn) {
+				panic("close hook")
+			},
+			OnHookPanic: func(err *internal.HookPanicError) {
+				panics <- err
+			},
+		}
+	})
+
+	conn, _ := pool.Get()
+	pool.Release(conn)
+
+	select {
+	case report := <-panics:
+		utils.AssertEqual(t, "OnConnectionClose", report.Hook, "Cleanup hook panic should be reported")
+	case <-time.After(3 * time.Second):
+		t.Fatal("Cleanup hook panic was not reported")
+	}
+}
+
+func TestRepanicHooks(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.RepanicHooks = true
+		c.Hooks = internal.PoolHooks{
+			OnConnectionCreate: func(conn net.Conn) {
+				panic("fail loudly")
+			},
+			OnHookPanic: func(err *internal.HookPanicError) {},
+		}
+	})
+
+	defer func() {
+		utils.AssertEqual(t, "fail loudly", recover(), "Panic should be re-raised")
+	}()
+	pool.Get()
+}
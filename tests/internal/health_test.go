@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+// waitForHealthChange returns the next health change, failing the test if none comes.
+func waitForHealthChange(t *testing.T, changes chan internal.Event) internal.Event {
+	select {
+	case e := <-changes:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("The health check should report a change")
+		return internal.Event{}
+	}
+}
+
+func TestUnhealthyEndpointIsSkipped(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+	dead := deadAddress(t)
+
+	changes := make(chan internal.Event, 8)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = dead
+		c.Endpoints = []string{address}
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.Hooks.OnHealthChange = func(e internal.Event) { changes <- e }
+		c.HealthCheck = &internal.HealthCheckConfig{Interval: 30 * time.Millisecond, Fall: 2}
+	})
+	defer pool.Close()
+
+	e := waitForHealthChange(t, changes)
+	utils.AssertEqual(t, internal.EventEndpointUnhealthy, e.Type, "The dead endpoint should be marked unhealthy")
+	utils.AssertEqual(t, dead, e.Address, "The event should name the dead endpoint")
+	utils.AssertNotNil(t, e.Err, "The event should carry the probe error")
+
+	var conns []net.Conn
+	for range 3 {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed on the healthy endpoint")
+		utils.AssertEqual(t, address, conn.(*internal.PooledConn).Info().Endpoint, "Connections should come from the healthy endpoint")
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		pool.Release(conn)
+	}
+
+	stats := pool.Stats().Endpoints
+	utils.AssertFalse(t, stats[dead].Healthy, "Stats should report the unhealthy endpoint")
+	utils.AssertNotNil(t, stats[dead].ProbeError, "Stats should report the last probe error")
+	utils.AssertEqual(t, uint64(0), stats[dead].Failures, "No dial should be paid for the unhealthy endpoint")
+	utils.AssertTrue(t, stats[address].Healthy, "Stats should report the healthy endpoint")
+	utils.AssertFalse(t, stats[address].LastProbe.IsZero(), "Stats should report when the endpoint was probed")
+}
+
+func TestGetFailsFastWithoutHealthyEndpoint(t *testing.T) {
+	dead := deadAddress(t)
+	changes := make(chan internal.Event, 8)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = dead
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.Hooks.OnHealthChange = func(e internal.Event) { changes <- e }
+		c.HealthCheck = &internal.HealthCheckConfig{Interval: 30 * time.Millisecond, Fall: 1}
+	})
+	defer pool.Close()
+
+	waitForHealthChange(t, changes)
+	start := time.Now()
+	_, err := pool.Get()
+	utils.AssertTrue(t, errors.Is(err, internal.ErrNoHealthyEndpoint), "Get should fail with ErrNoHealthyEndpoint")
+	utils.AssertTrue(t, time.Since(start) < 50*time.Millisecond, "Get should not wait for dials and backoff")
+	utils.AssertNotNil(t, pool.Stats().Endpoints, "Stats should report the endpoint of a health-checked pool")
+}
+
+func TestEndpointRecoversAfterRiseProbes(t *testing.T) {
+	address := deadAddress(t)
+	changes := make(chan internal.Event, 8)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.Hooks.OnHealthChange = func(e internal.Event) { changes <- e }
+		c.HealthCheck = &internal.HealthCheckConfig{Interval: 30 * time.Millisecond, Rise: 2, Fall: 1}
+	})
+	defer pool.Close()
+
+	utils.AssertEqual(t, internal.EventEndpointUnhealthy, waitForHealthChange(t, changes).Type, "The endpoint should be marked unhealthy")
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("Cannot listen on %s again: %v", address, err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	revived := time.Now()
+	utils.AssertEqual(t, internal.EventEndpointHealthy, waitForHealthChange(t, changes).Type, "The endpoint should be marked healthy again")
+	utils.AssertTrue(t, time.Since(revived) >= 30*time.Millisecond, "The endpoint should need two successful probes")
+	utils.AssertTrue(t, pool.Stats().Endpoints[address].Healthy, "Stats should report the recovery")
+}
+
+func TestFailingProbeMarksEndpointUnhealthy(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	errPing := errors.New("ping: unexpected reply")
+	changes := make(chan internal.Event, 8)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.Hooks.OnHealthChange = func(e internal.Event) { changes <- e }
+		c.HealthCheck = &internal.HealthCheckConfig{
+			Interval: 30 * time.Millisecond,
+			Fall:     1,
+			Probe: func(ctx context.Context, conn net.Conn) error {
+				_, hasDeadline := ctx.Deadline()
+				utils.AssertTrue(t, hasDeadline, "The probe should be bounded by the timeout")
+				return errPing
+			},
+		}
+	})
+	defer pool.Close()
+
+	e := waitForHealthChange(t, changes)
+	utils.AssertEqual(t, internal.EventEndpointUnhealthy, e.Type, "A failing probe should mark the endpoint unhealthy")
+	utils.AssertTrue(t, errors.Is(e.Err, errPing), "The event should carry the probe's error")
+}
+
+func TestReconfigureAppliesHealthCheck(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	errPing := errors.New("ping: unexpected reply")
+	probe := func(ctx context.Context, conn net.Conn) error { return errPing }
+	changes := make(chan internal.Event, 8)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 4
+		c.ConnTimeout = time.Second
+		c.Hooks.OnHealthChange = func(e internal.Event) { changes <- e }
+		c.HealthCheck = &internal.HealthCheckConfig{Interval: time.Hour, Fall: 3, Probe: probe}
+	})
+
+	config := internal.ConfigImpl{
+		Address:        address,
+		MaxConnections: 4,
+		ConnTimeout:    time.Second,
+		IdleTimeout:    10 * time.Second,
+		MaxRetries:     3,
+		Backoff:        &utils.MockBackoff{},
+	}
+	utils.AssertTrue(t, errors.Is(pool.Reconfigure(config), internal.ErrInvalidConfig), "Turning the health check off should be rejected")
+
+	config.HealthCheck = &internal.HealthCheckConfig{Interval: 20 * time.Millisecond, Fall: 1, Probe: probe}
+	utils.AssertNil(t, pool.Reconfigure(config), "Changing the health check settings should be accepted")
+	e := waitForHealthChange(t, changes)
+	utils.AssertEqual(t, internal.EventEndpointUnhealthy, e.Type, "The new interval and threshold should apply without waiting for the old interval")
+}
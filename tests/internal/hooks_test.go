@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"net"
 	"testing"
 	"time"
@@ -46,3 +48,138 @@ func TestPoolHooks(t *testing.T) {
 
 	utils.AssertTrue(t, hookTriggered, "OnConnectionCreate hook should be triggered")
 }
+
+func TestOnConnectRetriesRejectedSetup(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	attempts := 0
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks = internal.PoolHooks{
+			OnConnect: func(ctx context.Context, conn net.Conn) error {
+				attempts++
+				if attempts == 1 {
+					return errors.New("auth failed")
+				}
+				return nil
+			},
+		}
+	})
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Second dial attempt should succeed")
+	utils.AssertEqual(t, 2, attempts, "OnConnect should run once per dial attempt")
+	pool.Release(conn)
+}
+
+func TestOnConnectFailureIsDialError(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	setupErr := errors.New("unsupported protocol version")
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks = internal.PoolHooks{
+			OnConnect: func(ctx context.Context, conn net.Conn) error {
+				return setupErr
+			},
+		}
+	})
+
+	_, err := pool.Get()
+	utils.AssertTrue(t, errors.Is(err, setupErr), "Get should report the OnConnect error")
+	utils.AssertEqual(t, uint64(1), pool.Stats().DialErrors, "Rejected setup should count as a dial error")
+	utils.AssertEqual(t, 0, pool.Stats().Open, "Rejected connections should not stay open")
+}
+
+func TestOnBorrowRejectsIdleConnection(t *testing.T) {
+	serverConfig := utils.MockServerConfig{
+		SendData:     true,
+		Data:         []byte("test data"),
+		SendInterval: 20 * time.Millisecond,
+	}
+	server, address := utils.NewMockServer(t, serverConfig)
+	defer server.Stop()
+
+	borrows := 0
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks = internal.PoolHooks{
+			OnBorrow: func(ctx context.Context, conn net.Conn) error {
+				borrows++
+				if borrows == 2 {
+					return errors.New("stale session")
+				}
+				return nil
+			},
+		}
+	})
+
+	conn, _ := pool.Get()
+	pool.Release(conn)
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should fall back to a new connection")
+	utils.AssertEqual(t, 3, borrows, "OnBorrow should run for the idle and the new connection")
+
+	stats := pool.Stats()
+	utils.AssertEqual(t, uint64(2), stats.Created, "A replacement connection should be dialed")
+	utils.AssertEqual(t, uint64(1), stats.Discarded[internal.DiscardRejected], "Rejected idle connection should be discarded")
+	pool.Release(conn)
+}
+
+func TestOnReturnRejectsConnection(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Hooks = internal.PoolHooks{
+			OnReturn: func(conn net.Conn) error {
+				return errors.New("reset failed")
+			},
+		}
+	})
+
+	conn, _ := pool.Get()
+	pool.Release(conn)
+
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "Rejected connection should not be pooled")
+	utils.AssertEqual(t, uint64(1), pool.Stats().Discarded[internal.DiscardRejected], "Rejected connection should be discarded")
+}
+
+func TestDialAttemptAndRetryHooks(t *testing.T) {
+	var attempts, retries []internal.Event
+	var final internal.Event
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = "localhost:1"
+		c.Hooks = internal.PoolHooks{
+			OnDialAttempt: func(e internal.Event) {
+				attempts = append(attempts, e)
+			},
+			OnDialRetry: func(e internal.Event) {
+				retries = append(retries, e)
+			},
+			OnEvent: func(e internal.Event) {
+				if e.Type == internal.EventConnectionError {
+					final = e
+				}
+			},
+		}
+	})
+
+	_, err := pool.Get()
+	utils.AssertNotNil(t, err, "Dialing a closed port should fail")
+
+	utils.AssertEqual(t, 3, len(attempts), "Every dial attempt should be reported")
+	for i, e := range attempts {
+		utils.AssertEqual(t, uint(i+1), e.Attempt, "Attempts should be numbered")
+		utils.AssertEqual(t, "localhost:1", e.Address, "Attempts should carry the address")
+		utils.AssertNotNil(t, e.Err, "Failed attempts should carry their error")
+	}
+	utils.AssertEqual(t, 2, len(retries), "Every retry should be reported")
+	utils.AssertEqual(t, uint(1), retries[0].Attempt, "Retry should name the failed attempt")
+	utils.AssertEqual(t, uint(3), final.Attempt, "Final error should report the attempt count")
+	utils.AssertTrue(t, final.Duration >= attempts[2].Duration, "Final error should report the elapsed time")
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+// fakeConn is an idle connection placeholder; stores never call its methods.
+type fakeConn struct {
+	net.Conn
+	id int
+}
+
+func TestShardedStoreIsLIFO(t *testing.T) {
+	store := internal.NewShardedStore(1)
+	for i := 0; i < 3; i++ {
+		utils.AssertTrue(t, store.Push(internal.IdleConn{Conn: &fakeConn{id: i}}), "Push should always succeed")
+	}
+	utils.AssertEqual(t, 3, store.Len(), "Store should count its connections")
+
+	c, ok := store.Pop()
+	utils.AssertTrue(t, ok, "Pop should return a connection")
+	utils.AssertEqual(t, 2, c.Conn.(*fakeConn).id, "The most recently pushed connection should come first")
+}
+
+func TestShardedStoreStealsFromOtherShards(t *testing.T) {
+	store := internal.NewShardedStore(8)
+	for i := 0; i < 100; i++ {
+		store.Push(internal.IdleConn{Conn: &fakeConn{id: i}})
+	}
+
+	seen := make(map[int]bool)
+	for {
+		c, ok := store.Pop()
+		if !ok {
+			break
+		}
+		seen[c.Conn.(*fakeConn).id] = true
+	}
+	utils.AssertEqual(t, 100, len(seen), "Every connection should be found, whichever shard holds it")
+	utils.AssertEqual(t, 0, store.Len(), "Store should be empty")
+}
+
+func TestChannelStoreIsBounded(t *testing.T) {
+	store := internal.NewChannelStore(1)
+	utils.AssertTrue(t, store.Push(internal.IdleConn{Conn: &fakeConn{id: 1}}), "Push should succeed below capacity")
+	utils.AssertTrue(t, !store.Push(internal.IdleConn{Conn: &fakeConn{id: 2}}), "Push should fail at capacity")
+
+	c, ok := store.Pop()
+	utils.AssertTrue(t, ok, "Pop should return a connection")
+	utils.AssertEqual(t, 1, c.Conn.(*fakeConn).id, "The stored connection should be returned")
+	_, ok = store.Pop()
+	utils.AssertTrue(t, !ok, "Pop should fail on an empty store")
+}
+
+// BenchmarkIdleStore measures a Get/Release cycle (Pop, then Push with a fresh Since as Release
+// does) on an idle store holding 128 connections, from goroutines running in parallel on every
+// CPU, comparing the channel store with the sharded store. Compare the scaling with:
+//
+//	go test ./tests/internal -run '^$' -bench IdleStore -cpu 1,4,8
+func BenchmarkIdleStore(b *testing.B) {
+	stores := []struct {
+		name string
+		make func() internal.IdleStore
+	}{
+		{"channel", func() internal.IdleStore { return internal.NewChannelStore(128) }},
+		{"sharded", func() internal.IdleStore { return internal.NewShardedStore(0) }},
+	}
+
+	for _, s := range stores {
+		b.Run(s.name, func(b *testing.B) {
+			store := s.make()
+			base := time.Now()
+			for i := 0; i < 128; i++ {
+				store.Push(internal.IdleConn{Conn: &fakeConn{id: i}, Since: base.Add(time.Duration(i) * time.Millisecond)})
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if c, ok := store.Pop(); ok {
+						c.Since = time.Now()
+						store.Push(c)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestIdleOrders(t *testing.T) {
+	base := time.Now()
+	// Connection i was dialed at base+i and became idle at base-i: the oldest connection is the hottest.
+	push := func(store internal.IdleStore) {
+		for i := 0; i < 3; i++ {
+			store.Push(internal.IdleConn{
+				Conn:    &fakeConn{id: i},
+				Created: base.Add(time.Duration(i) * time.Second),
+				Since:   base.Add(-time.Duration(i) * time.Second),
+			})
+		}
+	}
+	cases := []struct {
+		order        internal.IdleOrder
+		next, retire int
+	}{
+		{internal.IdleLIFO, 0, 2},
+		{internal.IdleFIFO, 2, 1},
+		{internal.IdleRetireOldest, 2, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.order), func(t *testing.T) {
+			store := internal.NewIdleStore(tc.order)
+			if tc.order == internal.IdleLIFO {
+				// Only a single shard is strictly LIFO.
+				store = internal.NewShardedStore(1)
+			}
+			push(store)
+
+			c, _ := store.Pop()
+			utils.AssertEqual(t, tc.next, c.Conn.(*fakeConn).id, "Pop should follow the idle order")
+			c, _ = store.PopColdest()
+			utils.AssertEqual(t, tc.retire, c.Conn.(*fakeConn).id, "PopColdest should retire from the cold end")
+		})
+	}
+}
+
+func TestStoreRemoveIfKeepsOrder(t *testing.T) {
+	store := internal.NewOrderedStore(internal.IdleFIFO)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		store.Push(internal.IdleConn{Conn: &fakeConn{id: i}, Since: base.Add(time.Duration(i) * time.Second)})
+	}
+
+	removed := store.RemoveIf(func(c internal.IdleConn) bool { return c.Conn.(*fakeConn).id%2 == 1 })
+	utils.AssertEqual(t, 2, len(removed), "Matching connections should be removed")
+	utils.AssertEqual(t, 1, removed[0].Conn.(*fakeConn).id, "Removed connections should come coldest first")
+
+	var order []int
+	for {
+		c, ok := store.Pop()
+		if !ok {
+			break
+		}
+		order = append(order, c.Conn.(*fakeConn).id)
+	}
+	utils.AssertEqual(t, fmt.Sprint([]int{0, 2, 4}), fmt.Sprint(order), "Remaining connections should keep their order")
+}
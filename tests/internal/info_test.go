@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestConnInfoTracksUsage(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("hello"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+	})
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	pc := conn.(*internal.PooledConn)
+
+	_, err = conn.Write([]byte("ping"))
+	utils.AssertNil(t, err, "Write should succeed")
+	buf := make([]byte, 5)
+	n, err := conn.Read(buf)
+	utils.AssertNil(t, err, "Read should succeed")
+	pc.SetTag("tenant", "acme")
+
+	info := pc.Info()
+	utils.AssertEqual(t, uint64(1), info.ID, "The first connection should have ID 1")
+	utils.AssertEqual(t, address, info.Endpoint, "Endpoint should be the dialed address")
+	utils.AssertEqual(t, internal.ConnInUse, info.State, "A checked-out connection should be in use")
+	utils.AssertEqual(t, uint64(1), info.Uses, "The connection should have been used once")
+	utils.AssertEqual(t, uint64(4), info.BytesWritten, "Written bytes should be counted")
+	utils.AssertEqual(t, uint64(n), info.BytesRead, "Read bytes should be counted")
+	utils.AssertEqual(t, "acme", info.Tags["tenant"], "Tags should be reported")
+
+	appErr := errors.New("key not found")
+	utils.AssertNil(t, pool.ReleaseWithError(conn, appErr), "Release should succeed")
+	again, _ := pool.Get()
+	info = again.(*internal.PooledConn).Info()
+	utils.AssertEqual(t, uint64(1), info.ID, "The idle connection should be reused")
+	utils.AssertEqual(t, uint64(2), info.Uses, "Uses should count every checkout")
+	utils.AssertEqual(t, appErr, info.LastError, "The last error should be recorded")
+	tag, ok := again.(*internal.PooledConn).Tag("tenant")
+	utils.AssertTrue(t, ok && tag == "acme", "Tags should stay with the connection across checkouts")
+	pool.Release(again)
+}
+
+func TestSnapshotListsConnections(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 10
+	})
+	first, _ := pool.Get()
+	second, _ := pool.Get()
+	utils.AssertNil(t, pool.Release(first), "Release should succeed")
+
+	snapshot := pool.Snapshot()
+	utils.AssertEqual(t, 2, len(snapshot), "Every open connection should be listed")
+	utils.AssertEqual(t, uint64(1), snapshot[0].ID, "Connections should be ordered by ID")
+	utils.AssertEqual(t, internal.ConnIdle, snapshot[0].State, "A released connection should be idle")
+	utils.AssertEqual(t, internal.ConnInUse, snapshot[1].State, "A checked-out connection should be in use")
+
+	utils.AssertNil(t, pool.Discard(second), "Discard should succeed")
+	utils.AssertEqual(t, 1, len(pool.Snapshot()), "Closed connections should not be listed")
+}
+
+func TestEventsAndHooksSeeConnInfo(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	var closedID uint64
+	var pool *internal.ConnectionPool
+	pool = utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+		c.Hooks.OnConnectionClose = func(conn net.Conn) {
+			if info, ok := pool.ConnInfo(conn); ok {
+				closedID = info.ID
+			}
+		}
+	})
+	events := pool.Subscribe(internal.EventTypes(internal.EventConnectionAcquire), internal.SubscribeOptions{})
+	defer pool.Unsubscribe(events)
+
+	conn, _ := pool.Get()
+	e := <-events
+	utils.AssertEqual(t, uint64(1), e.ConnID, "Events should carry the connection ID")
+	utils.AssertNil(t, pool.Discard(conn), "Discard should succeed")
+	utils.AssertEqual(t, uint64(1), closedID, "The close hook should see the connection's metadata")
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestLeakReportedAfterThreshold(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	leaks := make(chan internal.LeakInfo, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.LeakThreshold = 50 * time.Millisecond
+		c.LeakStackSampleRate = 1
+		c.Hooks.OnConnectionLeak = func(info internal.LeakInfo) {
+			select {
+			case leaks <- info:
+			default:
+			}
+		}
+	})
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer pool.Release(conn)
+
+	select {
+	case info := <-leaks:
+		utils.AssertFalse(t, info.Collected, "Held connection should not be reported as collected")
+		utils.AssertTrue(t, info.Held >= 50*time.Millisecond, "Leak should be reported after the threshold")
+		utils.AssertTrue(t, strings.Contains(info.Stack, "TestLeakReportedAfterThreshold"), "Leak should carry the acquiring stack")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Leak was not reported")
+	}
+}
+
+func TestTinyLeakThreshold(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	leaks := make(chan internal.LeakInfo, 1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.LeakThreshold = time.Nanosecond
+		c.Hooks.OnConnectionLeak = func(info internal.LeakInfo) {
+			select {
+			case leaks <- info:
+			default:
+			}
+		}
+	})
+
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	defer pool.Release(conn)
+
+	select {
+	case <-leaks:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Leak was not reported")
+	}
+}
+
+func TestLeakCollectedHandleIsReclaimed(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	leaks := make(chan internal.LeakInfo, 2)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.LeakThreshold = 50 * time.Millisecond
+		c.LeakStackSampleRate = 1
+		c.Hooks.OnConnectionLeak = func(info internal.LeakInfo) {
+			select {
+			case leaks <- info:
+			default:
+			}
+		}
+	})
+
+	if _, err := pool.Get(); err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case info := <-leaks:
+			if info.Collected {
+				return
+			}
+		case <-deadline:
+			t.Fatal("Garbage-collected handle was not reported")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestReleaseTwice(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.LeakThreshold = 50 * time.Millisecond
+	})
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	utils.AssertNil(t, pool.Release(conn), "First release should succeed")
+	utils.AssertTrue(t, errors.Is(pool.Release(conn), internal.ErrConnAlreadyReleased), "Second release should be rejected")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "Connection should be pooled only once")
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestLimiterCapsAcrossPools(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	limiter, err := internal.NewLimiter(2)
+	utils.AssertNil(t, err, "Creating a limiter should succeed")
+	a := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+	b := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+
+	_, err = a.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+	_, err = b.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = a.GetContext(ctx)
+	utils.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "Get should wait once the shared limit is reached")
+
+	stats := limiter.Stats()
+	utils.AssertEqual(t, 2, stats.InUse, "Limiter should count connections of both pools")
+	utils.AssertEqual(t, 0, stats.Waiting, "An abandoned wait should leave the queue")
+	utils.AssertEqual(t, 2, stats.Pools, "Both pools should be registered")
+}
+
+func TestLimiterEvictsIdleFromOtherPools(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	limiter, _ := internal.NewLimiter(2)
+	a := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+	b := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+
+	first, _ := a.Get()
+	second, _ := a.Get()
+	utils.AssertNil(t, a.Release(first), "Release should succeed")
+	utils.AssertNil(t, a.Release(second), "Release should succeed")
+
+	_, err := b.Get()
+	utils.AssertNil(t, err, "Get should make room by evicting an idle connection")
+	utils.AssertEqual(t, 1, a.Stats().Idle, "One idle connection should be evicted")
+	utils.AssertEqual(t, uint64(1), limiter.Stats().Evicted, "The eviction should be counted")
+}
+
+func TestLimiterHandsReleasedCapacityToWaiters(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	limiter, _ := internal.NewLimiter(1)
+	a := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+	b := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+
+	conn, err := a.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+
+	got := make(chan error, 1)
+	go func() {
+		_, err := b.Get()
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	utils.AssertEqual(t, 1, limiter.Stats().Waiting, "Pool b should wait for a slot")
+
+	utils.AssertNil(t, a.Release(conn), "Release should succeed")
+	select {
+	case err := <-got:
+		utils.AssertNil(t, err, "Waiter should get the released capacity")
+	case <-time.After(time.Second):
+		t.Fatal("Waiter in another pool was not served")
+	}
+	utils.AssertEqual(t, 0, a.Stats().Open, "The released connection should be closed for the other pool")
+}
+
+func TestLimiterSetMax(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	limiter, _ := internal.NewLimiter(1)
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.Limiter = limiter
+	})
+	_, err := pool.Get()
+	utils.AssertNil(t, err, "Get should succeed")
+
+	got := make(chan error, 1)
+	go func() {
+		_, err := pool.Get()
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	utils.AssertNil(t, limiter.SetMax(2), "SetMax should succeed")
+
+	select {
+	case err := <-got:
+		utils.AssertNil(t, err, "Raising the limit should admit the waiter")
+	case <-time.After(time.Second):
+		t.Fatal("Waiter was not admitted after raising the limit")
+	}
+
+	_, err = internal.NewLimiter(0)
+	utils.AssertTrue(t, errors.Is(err, internal.ErrInvalidConfig), "A zero limit should be rejected")
+}
@@ -54,13 +54,47 @@ func TestPoolIdleTimeout(t *testing.T) {
 	err := pool.Release(conn)
 	utils.AssertNil(t, err, "Releasing a connection should not return an error")
 
-	initialIdleConns := len(pool.IdleConns)
+	initialIdleConns := pool.Stats().Idle
 	fmt.Printf("IdleConns before cleanup: %d\n", initialIdleConns)
 	utils.AssertEqual(t, 1, initialIdleConns, "There should be 1 idle connection before cleanup")
 
 	time.Sleep(3 * time.Second)
 
-	finalIdleConns := len(pool.IdleConns)
+	finalIdleConns := pool.Stats().Idle
 	fmt.Printf("IdleConns after cleanup: %d\n", finalIdleConns)
 	utils.AssertEqual(t, 0, finalIdleConns, "Idle connection should have been cleaned up")
 }
+
+func TestCleanupClosesExpiredIdleConnections(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 100 * time.Millisecond})
+	defer server.Stop()
+
+	config := internal.ConfigImpl{
+		Address:        address,
+		MaxConnections: 5,
+		ConnTimeout:    2 * time.Second,
+		IdleTimeout:    500 * time.Millisecond,
+		MaxRetries:     3,
+		Backoff:        &utils.MockBackoff{},
+		IdleOrder:      internal.IdleLIFO,
+	}
+	pool, _ := internal.NewConnectionPool(config)
+
+	conn, _ := pool.Get()
+	utils.AssertNil(t, pool.Release(conn), "Releasing a connection should not return an error")
+	time.Sleep(1200 * time.Millisecond)
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "A connection idle for longer than the idle timeout should be closed")
+}
+
+func TestInvalidIdleOrder(t *testing.T) {
+	config := internal.ConfigImpl{
+		Address:        "localhost:1",
+		MaxConnections: 1,
+		IdleTimeout:    time.Second,
+		MaxRetries:     1,
+		Backoff:        &utils.MockBackoff{},
+		IdleOrder:      "random",
+	}
+	_, err := internal.NewConnectionPool(config)
+	utils.AssertTrue(t, err != nil, "An unknown idle order should be rejected")
+}
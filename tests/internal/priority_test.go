@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+// waitForWaiters blocks until n callers of prio are waiting.
+func waitForWaiters(t *testing.T, pool *internal.ConnectionPool, prio internal.Priority, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Stats().Waits[prio].Waiting != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d %v waiters", n, prio)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHighPriorityWaitersAreServedFirst(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 1
+	})
+	held, _ := pool.Get()
+
+	got := make(chan internal.Priority, 2)
+	conns := make(chan net.Conn, 2)
+	acquire := func(prio internal.Priority) {
+		conn, err := pool.GetContext(internal.ContextWithPriority(context.Background(), prio))
+		if err == nil {
+			got <- prio
+			conns <- conn
+		}
+	}
+	go acquire(internal.PriorityLow)
+	waitForWaiters(t, pool, internal.PriorityLow, 1)
+	go acquire(internal.PriorityHigh)
+	waitForWaiters(t, pool, internal.PriorityHigh, 1)
+
+	utils.AssertNil(t, pool.Release(held), "Release should succeed")
+	utils.AssertEqual(t, internal.PriorityHigh, <-got, "The high-priority waiter should be served first")
+	utils.AssertNil(t, pool.Release(<-conns), "Release should succeed")
+	utils.AssertEqual(t, internal.PriorityLow, <-got, "The low-priority waiter should be served next")
+	pool.Release(<-conns)
+
+	waits := pool.Stats().Waits
+	utils.AssertEqual(t, uint64(1), waits[internal.PriorityLow].Acquired, "Low-priority acquisitions should be counted")
+	utils.AssertEqual(t, uint64(1), waits[internal.PriorityHigh].Acquired, "High-priority acquisitions should be counted")
+	utils.AssertEqual(t, uint64(1), waits[internal.PriorityNormal].Acquired, "Acquisitions without a priority should count as normal")
+	utils.AssertTrue(t, waits[internal.PriorityLow].MaxWait > waits[internal.PriorityHigh].MaxWait, "The low-priority caller should have waited longer")
+}
+
+func TestReservedConnectionsAreKeptForHighPriority(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+		c.ReservedConnections = 1
+	})
+	normal, err := pool.Get()
+	utils.AssertNil(t, err, "A normal caller should get an unreserved connection")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetContext(ctx)
+	utils.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "A normal caller should not get the reserved connection")
+
+	high, err := pool.GetContext(internal.ContextWithPriority(context.Background(), internal.PriorityHigh))
+	utils.AssertNil(t, err, "A high-priority caller should get the reserved connection")
+	utils.AssertEqual(t, 2, pool.Stats().Open, "The reserved connection should have been dialed")
+
+	utils.AssertNil(t, pool.Release(normal), "Release should succeed")
+	again, err := pool.Get()
+	utils.AssertNil(t, err, "A normal caller should get a connection once the share is free")
+	pool.Release(again)
+	pool.Release(high)
+}
+
+func TestReservedConnectionsMustLeaveSharedCapacity(t *testing.T) {
+	config := internal.ConfigImpl{
+		Address:             "localhost:1",
+		MaxConnections:      2,
+		IdleTimeout:         time.Second,
+		MaxRetries:          1,
+		Backoff:             &utils.MockBackoff{},
+		ReservedConnections: 2,
+	}
+	_, err := internal.NewConnectionPool(config)
+	utils.AssertTrue(t, errors.Is(err, internal.ErrInvalidConfig), "Reserving every connection should be rejected")
+
+	config.ReservedConnections = 1
+	pool, err := internal.NewConnectionPool(config)
+	utils.AssertNil(t, err, "Pool creation should succeed")
+	utils.AssertNotNil(t, pool.Resize(1), "Resizing below the reservation should be rejected")
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestGetWaitsAtCapacity(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 1
+	})
+	conn, err := pool.Get()
+	utils.AssertNil(t, err, "First Get should succeed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetContext(ctx)
+	utils.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "Get should wait while the pool is at capacity")
+
+	got := make(chan net.Conn, 1)
+	go func() {
+		c, _ := pool.Get()
+		got <- c
+	}()
+	time.Sleep(20 * time.Millisecond)
+	utils.AssertNil(t, pool.Release(conn), "Release should succeed")
+
+	select {
+	case c := <-got:
+		utils.AssertTrue(t, c != nil, "Waiter should get a connection after a release")
+		utils.AssertEqual(t, 1, pool.Stats().Open, "Pool should not exceed its capacity")
+	case <-time.After(3 * time.Second):
+		t.Fatal("Waiter was not woken by the release")
+	}
+}
+
+func TestResizeGrowAdmitsWaiters(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 1
+	})
+	_, err := pool.Get()
+	utils.AssertNil(t, err, "First Get should succeed")
+
+	got := make(chan error, 1)
+	go func() {
+		_, err := pool.Get()
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	utils.AssertNil(t, pool.Resize(2), "Resize should succeed")
+
+	select {
+	case err := <-got:
+		utils.AssertNil(t, err, "Waiter should get a new connection")
+		utils.AssertEqual(t, 2, pool.Stats().Open, "Pool should have grown")
+	case <-time.After(time.Second):
+		t.Fatal("Waiter was not admitted after growing the pool")
+	}
+}
+
+func TestResizeShrinkClosesSurplus(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 3
+	})
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := pool.Get()
+		utils.AssertNil(t, err, "Get should succeed")
+		conns = append(conns, conn)
+	}
+	utils.AssertNil(t, pool.Release(conns[0]), "Release should succeed")
+	utils.AssertNil(t, pool.Release(conns[1]), "Release should succeed")
+
+	utils.AssertNil(t, pool.Resize(1), "Resize should succeed")
+	utils.AssertEqual(t, 1, pool.Stats().Open, "Surplus idle connections should be closed")
+	utils.AssertEqual(t, 0, pool.Stats().Idle, "No idle connection should be left")
+
+	utils.AssertNil(t, pool.Release(conns[2]), "Release should succeed")
+	utils.AssertEqual(t, 1, pool.Stats().Idle, "A connection within the limit should be pooled")
+
+	utils.AssertTrue(t, pool.Resize(0) != nil, "Resizing to zero should be rejected")
+}
+
+func TestReconfigure(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 2
+	})
+	config := internal.ConfigImpl{
+		Address:        address,
+		MaxConnections: 4,
+		ConnTimeout:    time.Second,
+		IdleTimeout:    5 * time.Second,
+		MaxRetries:     1,
+		Backoff:        &utils.MockBackoff{},
+		DoRetries:      2,
+	}
+	utils.AssertNil(t, pool.Reconfigure(config), "Reconfigure should succeed")
+	utils.AssertEqual(t, 4, pool.MaxConnections, "MaxConnections should be updated")
+	utils.AssertEqual(t, uint(1), pool.MaxRetries, "MaxRetries should be updated")
+	utils.AssertEqual(t, uint(2), pool.DoRetries, "DoRetries should be updated")
+
+	config.Address = "localhost:1"
+	err := pool.Reconfigure(config)
+	utils.AssertTrue(t, errors.Is(err, internal.ErrInvalidConfig), "Changing the address should be rejected")
+
+	config.Address = address
+	config.MaxRetries = 0
+	utils.AssertTrue(t, pool.Reconfigure(config) != nil, "An invalid config should be rejected")
+	utils.AssertEqual(t, uint(1), pool.MaxRetries, "A rejected config should not be applied")
+}
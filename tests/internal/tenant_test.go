@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestTenantMaxIsEnforced(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.TenantQuotas = map[string]internal.TenantQuota{"acme": {Max: 2}}
+	})
+	acme := internal.ContextWithTenant(context.Background(), "acme")
+	first, err := pool.GetContext(acme)
+	utils.AssertNil(t, err, "The tenant should get a connection within its quota")
+	second, err := pool.GetContext(acme)
+	utils.AssertNil(t, err, "The tenant should get a connection within its quota")
+
+	_, err = pool.GetContext(acme)
+	utils.AssertTrue(t, errors.Is(err, internal.ErrQuotaExceeded), "The tenant should be refused beyond its quota")
+	other, err := pool.GetContext(internal.ContextWithTenant(context.Background(), "globex"))
+	utils.AssertNil(t, err, "Other tenants should not be affected")
+
+	stats := pool.Stats().Tenants["acme"]
+	utils.AssertEqual(t, 2, stats.InUse, "Stats should count the tenant's connections")
+	utils.AssertEqual(t, uint64(2), stats.Acquired, "Stats should count the tenant's acquisitions")
+	utils.AssertEqual(t, uint64(1), stats.Rejected, "Stats should count the refused acquisition")
+	utils.AssertEqual(t, 3, pool.InUse(), "InUse should count the checkouts of every tenant")
+
+	utils.AssertNil(t, pool.Release(first), "Release should succeed")
+	third, err := pool.GetContext(acme)
+	utils.AssertNil(t, err, "The tenant should get a connection once it is back under its quota")
+	pool.Release(second)
+	pool.Release(third)
+	pool.Release(other)
+}
+
+func TestTenantMinimumIsGuaranteed(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 3
+		c.TenantQuotas = map[string]internal.TenantQuota{"vip": {Min: 1}}
+	})
+	noisy := internal.ContextWithTenant(context.Background(), "noisy")
+	a, err := pool.GetContext(noisy)
+	utils.AssertNil(t, err, "A tenant should get unguaranteed capacity")
+	b, err := pool.GetContext(context.Background())
+	utils.AssertNil(t, err, "Callers without a tenant should get unguaranteed capacity")
+
+	ctx, cancel := context.WithTimeout(noisy, 100*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetContext(ctx)
+	utils.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "The connection guaranteed to another tenant should not be taken")
+
+	vip, err := pool.GetContext(internal.ContextWithTenant(context.Background(), "vip"))
+	utils.AssertNil(t, err, "The tenant should get its guaranteed connection")
+	pool.Release(a)
+	pool.Release(b)
+	pool.Release(vip)
+}
+
+func TestTenantQuotasChangeAtRuntime(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.TenantQuotas = map[string]internal.TenantQuota{"acme": {Max: 1}}
+	})
+	acme := internal.ContextWithTenant(context.Background(), "acme")
+	first, _ := pool.GetContext(acme)
+	_, err := pool.GetContext(acme)
+	utils.AssertTrue(t, errors.Is(err, internal.ErrQuotaExceeded), "The tenant should be refused beyond its quota")
+
+	utils.AssertNil(t, pool.SetTenantQuota("acme", internal.TenantQuota{Max: 2}), "A valid quota should be accepted")
+	second, err := pool.GetContext(acme)
+	utils.AssertNil(t, err, "A raised quota should apply to the next acquisition")
+	utils.AssertEqual(t, 2, pool.Stats().Tenants["acme"].Quota.Max, "Stats should report the quota in effect")
+
+	utils.AssertNotNil(t, pool.SetTenantQuota("acme", internal.TenantQuota{Max: 1, Min: 2}), "A minimum above the maximum should be rejected")
+	pool.RemoveTenantQuota("acme")
+	third, err := pool.GetContext(acme)
+	utils.AssertNil(t, err, "Without a quota the tenant should not be limited")
+	pool.Release(first)
+	pool.Release(second)
+	pool.Release(third)
+}
+
+func TestTenantsWithoutQuotaAreForgotten(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{SendData: true, Data: []byte("x"), SendInterval: 20 * time.Millisecond})
+	defer server.Stop()
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = address
+		c.MaxConnections = 3
+		c.TenantQuotas = map[string]internal.TenantQuota{"acme": {Max: 2}}
+	})
+	for i := 0; i < 10; i++ {
+		conn, err := pool.GetContext(internal.ContextWithTenant(context.Background(), fmt.Sprintf("request-%d", i)))
+		utils.AssertNil(t, err, "Get should succeed")
+		utils.AssertEqual(t, 2, len(pool.Stats().Tenants), "A tenant holding a connection should be listed")
+		pool.Release(conn)
+	}
+
+	tenants := pool.Stats().Tenants
+	utils.AssertEqual(t, 1, len(tenants), "Tenants without a quota should be forgotten once they hold nothing")
+	_, ok := tenants["acme"]
+	utils.AssertTrue(t, ok, "Tenants with a quota should stay listed")
+}
+
+func TestTenantMinimumsMustFit(t *testing.T) {
+	config := internal.ConfigImpl{
+		Address:             "localhost:1",
+		MaxConnections:      4,
+		IdleTimeout:         time.Second,
+		MaxRetries:          1,
+		Backoff:             &utils.MockBackoff{},
+		ReservedConnections: 1,
+		TenantQuotas:        map[string]internal.TenantQuota{"a": {Min: 2}, "b": {Min: 2}},
+	}
+	utils.AssertTrue(t, errors.Is(config.Validate(), internal.ErrInvalidConfig), "Minimums beyond the unreserved connections should be rejected")
+
+	pool := utils.NewTestPool(t, func(c *internal.ConfigImpl) {
+		c.Address = "localhost:1"
+		c.MaxConnections = 4
+		c.TenantQuotas = map[string]internal.TenantQuota{"a": {Min: 2}, "b": {Min: 1}}
+	})
+	utils.AssertTrue(t, errors.Is(pool.Resize(2), internal.ErrInvalidConfig), "Shrinking below the minimums should be rejected")
+	utils.AssertNil(t, pool.Resize(3), "Shrinking to the minimums should be accepted")
+	utils.AssertTrue(t, errors.Is(pool.SetTenantQuota("c", internal.TenantQuota{Min: 1}), internal.ErrInvalidConfig), "A minimum that no longer fits should be rejected")
+	_, ok := pool.Stats().Tenants["c"]
+	utils.AssertFalse(t, ok, "A rejected quota should not be applied")
+}
@@ -0,0 +1,304 @@
+package tcppool
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/internal/backoff"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	c := pool.NewConfigWith("localhost:9999",
+		pool.WithName("json-pool"),
+		pool.WithMaxConnections(7),
+		pool.WithConnTimeout(3*time.Second),
+		pool.WithBackoff(pool.NewFibonacciBackoff(20)),
+		pool.WithDoRetries(2),
+	)
+
+	data, err := json.Marshal(c)
+	utils.AssertNil(t, err, "Marshalling a config should not return an error")
+
+	var decoded pool.Config
+	utils.AssertNil(t, json.Unmarshal(data, &decoded), "Unmarshalling a config should not return an error")
+	utils.AssertEqual(t, "json-pool", decoded.Name(), "Name should round-trip")
+	utils.AssertEqual(t, "localhost:9999", decoded.Address(), "Address should round-trip")
+	utils.AssertEqual(t, 7, decoded.MaxConnections(), "MaxConnections should round-trip")
+	utils.AssertEqual(t, 3*time.Second, decoded.ConnTimeout(), "ConnTimeout should round-trip")
+	utils.AssertEqual(t, uint(2), decoded.DoRetries(), "DoRetries should round-trip")
+
+	name, params := decoded.Backoff().(backoff.Describer).Describe()
+	utils.AssertEqual(t, "fibonacci", name, "Backoff type should round-trip")
+	utils.AssertEqual(t, uint(20), params["max_delay"], "Backoff parameters should round-trip")
+}
+
+func TestConfigUnmarshalKeepsUnsetFields(t *testing.T) {
+	c := pool.NewConfigWith("localhost:9999", pool.WithMaxConnections(4))
+	utils.AssertNil(t, json.Unmarshal([]byte(`{"idle_timeout": "1m"}`), c), "Partial document should decode")
+	utils.AssertEqual(t, 4, c.MaxConnections(), "Unset fields should keep their values")
+	utils.AssertEqual(t, time.Minute, c.IdleTimeout(), "Set fields should be overridden")
+}
+
+func TestConfigUnmarshalUnknownBackoff(t *testing.T) {
+	var c pool.Config
+	err := json.Unmarshal([]byte(`{"address": "localhost:1", "backoff": {"type": "bogus"}}`), &c)
+	utils.AssertTrue(t, err != nil, "Unknown backoff should be rejected")
+}
+
+func TestRegisterBackoff(t *testing.T) {
+	pool.RegisterBackoff("test-constant", func(params map[string]uint) (backoff.Backoff, error) {
+		return pool.NewFixedBackoff(params["interval"]), nil
+	})
+
+	var c pool.Config
+	err := json.Unmarshal([]byte(`{"address": "localhost:1", "backoff": {"type": "test-constant", "interval": 3}}`), &c)
+	utils.AssertNil(t, err, "Registered backoff should be usable")
+	utils.AssertTrue(t, c.Backoff() != nil, "Backoff should be set")
+}
+
+func TestLoadConfigsFromYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.yaml")
+	doc := strings.Join([]string{
+		"# pool definitions",
+		"pools:",
+		"  cache:",
+		"    address: localhost:11211",
+		"    max_connections: 20",
+		"    idle_timeout: 45s",
+		"    backoff:",
+		"      type: linear",
+		"      scalar: 2",
+		"  db:",
+		"    name: primary-db",
+		"    address: localhost:5432",
+		"",
+	}, "\n")
+	utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+	t.Setenv("TCPPOOL_CACHE_MAX_CONNECTIONS", "30")
+
+	configs, err := pool.LoadConfigs(path, pool.WithConnTimeout(time.Second))
+	utils.AssertNil(t, err, "Loading configs should not return an error")
+	utils.AssertEqual(t, 2, len(configs), "Both pools should be loaded")
+
+	cache := configs["cache"]
+	utils.AssertEqual(t, "cache", cache.Name(), "Pool name should default to its key")
+	utils.AssertEqual(t, 30, cache.MaxConnections(), "Environment should override the file")
+	utils.AssertEqual(t, 45*time.Second, cache.IdleTimeout(), "File settings should be applied")
+	utils.AssertEqual(t, time.Second, cache.ConnTimeout(), "Options should provide the base settings")
+	name, params := cache.Backoff().(backoff.Describer).Describe()
+	utils.AssertEqual(t, "linear", name, "Backoff should be loaded from the file")
+	utils.AssertEqual(t, uint(2), params["scalar"], "Backoff parameters should be loaded from the file")
+
+	utils.AssertEqual(t, "primary-db", configs["db"].Name(), "An explicit name should win over the key")
+}
+
+func TestLoadConfigsFromJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	doc := `{"pools": {"api": {"address": "localhost:8080", "max_retries": 5}}}`
+	utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+
+	configs, err := pool.LoadConfigs(path)
+	utils.AssertNil(t, err, "Loading configs should not return an error")
+	utils.AssertEqual(t, uint(5), configs["api"].MaxRetries(), "JSON settings should be applied")
+	utils.AssertEqual(t, pool.DefaultMaxConnections, configs["api"].MaxConnections(), "Unset settings should keep defaults")
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("TCPPOOL_SESSION_STORE_ADDRESS", "localhost:6379")
+	t.Setenv("TCPPOOL_SESSION_STORE_CONN_TIMEOUT", "750ms")
+	t.Setenv("TCPPOOL_SESSION_STORE_DIRTY_CHECK", "true")
+	t.Setenv("TCPPOOL_SESSION_STORE_BACKOFF_MAX_DELAY", "4")
+
+	c, err := pool.ConfigFromEnv("session-store")
+	utils.AssertNil(t, err, "Loading from the environment should not return an error")
+	utils.AssertEqual(t, "localhost:6379", c.Address(), "Address should come from the environment")
+	utils.AssertEqual(t, 750*time.Millisecond, c.ConnTimeout(), "Durations should be parsed")
+	utils.AssertTrue(t, c.DirtyCheck(), "Booleans should be parsed")
+	name, params := c.Backoff().(backoff.Describer).Describe()
+	utils.AssertEqual(t, "exponential", name, "Backoff type should default to the current strategy")
+	utils.AssertEqual(t, uint(4), params["max_delay"], "Backoff parameters should be overridden")
+
+	_, err = pool.ConfigFromEnv("missing")
+	utils.AssertTrue(t, err != nil, "A pool without an address should be rejected")
+}
+
+func TestConfigJSONTenantQuotas(t *testing.T) {
+	var c pool.Config
+	doc := `{"address": "localhost:1", "max_connections": 4, "idle_timeout": "1s", "max_retries": 1,
+		"tenant_quotas": {"acme": {"max": 2, "min": 1}}, "default_tenant_quota": {"max": 3}}`
+	utils.AssertNil(t, json.Unmarshal([]byte(doc), &c), "Tenant quotas should decode")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 2, Min: 1}, c.TenantQuotas()["acme"], "Tenant quotas should be read")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 3}, c.DefaultTenantQuota(), "The default tenant quota should be read")
+
+	data, err := json.Marshal(&c)
+	utils.AssertNil(t, err, "Marshalling a config should not return an error")
+	utils.AssertTrue(t, strings.Contains(string(data), `"tenant_quotas":{"acme":{"max":2,"min":1}}`), "Tenant quotas should be written")
+}
+
+func TestConfigJSONAutoscale(t *testing.T) {
+	var c pool.Config
+	doc := `{"address": "localhost:1", "max_connections": 4, "idle_timeout": "1s", "max_retries": 1,
+		"autoscale": {"min": 2, "max": 16, "algorithm": "aimd", "interval": "500ms", "latency_threshold": "50ms"}}`
+	utils.AssertNil(t, json.Unmarshal([]byte(doc), &c), "Autoscaling settings should decode")
+	want := pool.AutoscaleConfig{Min: 2, Max: 16, Algorithm: pool.ScaleAIMD, Interval: 500 * time.Millisecond, LatencyThreshold: 50 * time.Millisecond}
+	utils.AssertEqual(t, want, *c.Autoscale(), "Autoscaling settings should be read")
+
+	data, err := json.Marshal(&c)
+	utils.AssertNil(t, err, "Marshalling a config should not return an error")
+	utils.AssertTrue(t, strings.Contains(string(data), `"latency_threshold":"50ms"`), "Autoscaling durations should be written as strings")
+}
+
+func TestConfigJSONEndpoints(t *testing.T) {
+	var c pool.Config
+	doc := `{"address": "localhost:1", "endpoints": ["localhost:2", "localhost:3"], "max_connections": 4,
+		"idle_timeout": "1s", "max_retries": 1,
+		"outlier_detection": {"interval": "5s", "min_hosts": 3, "failure_threshold": 0.5, "base_ejection_time": "10s"}}`
+	utils.AssertNil(t, json.Unmarshal([]byte(doc), &c), "Endpoints should decode")
+	utils.AssertEqual(t, []string{"localhost:2", "localhost:3"}, c.Endpoints(), "Endpoints should be read")
+	want := pool.OutlierConfig{Interval: 5 * time.Second, MinHosts: 3, FailureThreshold: 0.5, BaseEjectionTime: 10 * time.Second}
+	utils.AssertEqual(t, want, *c.OutlierDetection(), "Outlier detection settings should be read")
+
+	data, err := json.Marshal(&c)
+	utils.AssertNil(t, err, "Marshalling a config should not return an error")
+	utils.AssertTrue(t, strings.Contains(string(data), `"base_ejection_time":"10s"`), "Outlier detection durations should be written as strings")
+}
+
+func TestConfigJSONHealthCheckKeepsProbe(t *testing.T) {
+	probed := false
+	c := pool.NewConfigWith("localhost:1", pool.WithHealthCheck(pool.HealthCheckConfig{
+		Probe: func(context.Context, net.Conn) error { probed = true; return nil },
+	}))
+	doc := `{"address": "localhost:1", "max_connections": 4, "idle_timeout": "1s", "max_retries": 1,
+		"health_check": {"interval": "2s", "rise": 1, "fall": 2}}`
+	utils.AssertNil(t, json.Unmarshal([]byte(doc), c), "Health check settings should decode")
+
+	check := c.HealthCheck()
+	utils.AssertEqual(t, 2*time.Second, check.Interval, "The interval should be read")
+	utils.AssertEqual(t, 2, check.Fall, "The fall threshold should be read")
+	utils.AssertNotNil(t, check.Probe, "The probe should be kept")
+	check.Probe(context.Background(), nil)
+	utils.AssertTrue(t, probed, "The kept probe should be the configured one")
+}
+
+func TestConfigUnmarshalReplacesTenantQuotas(t *testing.T) {
+	c := pool.NewConfigWith("localhost:1", pool.WithTenantQuota("acme", pool.TenantQuota{Max: 2}))
+	utils.AssertNil(t, json.Unmarshal([]byte(`{"tenant_quotas": {"beta": {"max": 1}}}`), c), "Tenant quotas should decode")
+	utils.AssertEqual(t, map[string]pool.TenantQuota{"beta": {Max: 1}}, c.TenantQuotas(), "Decoded quotas should replace the current ones")
+
+	utils.AssertNil(t, json.Unmarshal([]byte(`{"idle_timeout": "1s"}`), c), "Partial document should decode")
+	utils.AssertEqual(t, 1, len(c.TenantQuotas()), "Quotas absent from the document should be kept")
+}
+
+func TestLoadConfigsMatchesEnvironmentNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	doc := `{"pools": {"my-cache": {"address": "localhost:11211"}}}`
+	utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+	t.Setenv("TCPPOOL_MY_CACHE_ADDRESS", "localhost:11212")
+	t.Setenv("TCPPOOL_SESSIONS_ADDRESS", "localhost:6379")
+
+	configs, err := pool.LoadConfigs(path)
+	utils.AssertNil(t, err, "Loading configs should not return an error")
+	utils.AssertEqual(t, 2, len(configs), "The environment should override the file's pool, not add one")
+	utils.AssertEqual(t, "localhost:11212", configs["my-cache"].Address(), "The environment should override the file")
+	utils.AssertEqual(t, "localhost:6379", configs["sessions"].Address(), "Pools only in the environment should be added")
+}
+
+func TestLoadConfigsYAMLSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.yml")
+	doc := strings.Join([]string{
+		"pools:",
+		"  cache:",
+		"    address: localhost:11211",
+		"    endpoints:",
+		"      - localhost:11212",
+		"      - 'localhost:11213'",
+		"    health_check:",
+		"      interval: 5s",
+		"      fall: 2",
+		"    outlier_detection:",
+		"      interval: 10s",
+		"      failure_threshold: 0.5",
+		"    autoscale:",
+		"      min: 2",
+		"      max: 16",
+		"      algorithm: aimd",
+		"    tenant_quotas:",
+		"      acme:",
+		"        max: 4",
+		"  db:",
+		"    address: localhost:5432",
+		"    endpoints: [localhost:5433, \"localhost:5434\"]",
+		"",
+	}, "\n")
+	utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+
+	configs, err := pool.LoadConfigs(path)
+	utils.AssertNil(t, err, "Loading configs should not return an error")
+	cache := configs["cache"]
+	utils.AssertEqual(t, []string{"localhost:11212", "localhost:11213"}, cache.Endpoints(), "Block sequences should be read")
+	utils.AssertEqual(t, 5*time.Second, cache.HealthCheck().Interval, "The health check should be read")
+	utils.AssertEqual(t, 2, cache.HealthCheck().Fall, "The health check should be read")
+	utils.AssertEqual(t, 0.5, cache.OutlierDetection().FailureThreshold, "Outlier detection should be read")
+	utils.AssertEqual(t, 16, cache.Autoscale().Max, "Autoscaling should be read")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 4}, cache.TenantQuotas()["acme"], "Tenant quotas should be read")
+	utils.AssertEqual(t, []string{"localhost:5433", "localhost:5434"}, configs["db"].Endpoints(), "Flow sequences should be read")
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	utils.AssertNil(t, os.WriteFile(bad, []byte("pools:\n  cache:\n    endpoints:\n      - host: a\n"), 0o600), "Writing the config file should succeed")
+	_, err = pool.LoadConfigs(bad)
+	utils.AssertNotNil(t, err, "Sequences of mappings should be rejected")
+}
+
+func TestLoadConfigsJSONSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	doc := `{"pools": {"cache": {"address": "localhost:11211", "endpoints": ["localhost:11212"],
+		"health_check": {"interval": "5s"}, "autoscale": {"min": 2, "max": 16, "algorithm": "gradient"},
+		"default_tenant_quota": {"max": 3}}}}`
+	utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+
+	configs, err := pool.LoadConfigs(path)
+	utils.AssertNil(t, err, "Loading configs should not return an error")
+	cache := configs["cache"]
+	utils.AssertEqual(t, []string{"localhost:11212"}, cache.Endpoints(), "Endpoints should be read")
+	utils.AssertEqual(t, 5*time.Second, cache.HealthCheck().Interval, "The health check should be read")
+	utils.AssertEqual(t, pool.ScaleGradient, cache.Autoscale().Algorithm, "Autoscaling should be read")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 3}, cache.DefaultTenantQuota(), "The default tenant quota should be read")
+}
+
+func TestConfigFromEnvSections(t *testing.T) {
+	t.Setenv("TCPPOOL_CACHE_ADDRESS", "localhost:11211")
+	t.Setenv("TCPPOOL_CACHE_ENDPOINTS", "localhost:11212, localhost:11213")
+	t.Setenv("TCPPOOL_CACHE_HEALTH_CHECK_INTERVAL", "5s")
+	t.Setenv("TCPPOOL_CACHE_HEALTH_CHECK_RISE", "3")
+	t.Setenv("TCPPOOL_CACHE_OUTLIER_DETECTION_MIN_REQUESTS", "20")
+	t.Setenv("TCPPOOL_CACHE_OUTLIER_DETECTION_BASE_EJECTION_TIME", "30s")
+	t.Setenv("TCPPOOL_CACHE_AUTOSCALE_MIN", "2")
+	t.Setenv("TCPPOOL_CACHE_AUTOSCALE_MAX", "16")
+	t.Setenv("TCPPOOL_CACHE_AUTOSCALE_ALGORITHM", "aimd")
+	t.Setenv("TCPPOOL_CACHE_TENANT_QUOTAS", `{"acme": {"max": 4, "min": 1}}`)
+	t.Setenv("TCPPOOL_CACHE_DEFAULT_TENANT_QUOTA_MAX", "2")
+
+	c, err := pool.ConfigFromEnv("cache")
+	utils.AssertNil(t, err, "Loading from the environment should not return an error")
+	utils.AssertEqual(t, []string{"localhost:11212", "localhost:11213"}, c.Endpoints(), "Endpoints should be comma-separated")
+	utils.AssertEqual(t, 5*time.Second, c.HealthCheck().Interval, "The health check interval should be read")
+	utils.AssertEqual(t, 3, c.HealthCheck().Rise, "The health check thresholds should be read")
+	utils.AssertEqual(t, 20, c.OutlierDetection().MinRequests, "Outlier detection should be read")
+	utils.AssertEqual(t, 30*time.Second, c.OutlierDetection().BaseEjectionTime, "Outlier detection durations should be read")
+	want := pool.AutoscaleConfig{Min: 2, Max: 16, Algorithm: pool.ScaleAIMD}
+	utils.AssertEqual(t, want, *c.Autoscale(), "Autoscaling should be read")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 4, Min: 1}, c.TenantQuotas()["acme"], "Tenant quotas should be read as JSON")
+	utils.AssertEqual(t, pool.TenantQuota{Max: 2}, c.DefaultTenantQuota(), "The default tenant quota should be read")
+
+	t.Setenv("TCPPOOL_CACHE_TENANT_QUOTAS", "acme=4")
+	_, err = pool.ConfigFromEnv("cache")
+	utils.AssertNotNil(t, err, "Malformed tenant quotas should be rejected")
+}
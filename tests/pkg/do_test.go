@@ -0,0 +1,38 @@
+package tcppool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestDoValue(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	config := pool.NewConfig(
+		address,
+		"do-pool",
+		5,
+		2*time.Second,
+		10*time.Second,
+		3,
+		pool.NewFixedBackoff(1),
+		pool.PoolHooks{},
+	)
+	p, err := pool.NewWithConfig(*config)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+
+	remote, err := pool.DoValue(context.Background(), p, func(conn net.Conn) (string, error) {
+		return conn.RemoteAddr().String(), nil
+	})
+
+	utils.AssertNil(t, err, "DoValue should succeed")
+	utils.AssertEqual(t, address, remote, "DoValue should return the callback's value")
+}
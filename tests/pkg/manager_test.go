@@ -0,0 +1,120 @@
+package tcppool
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestManagerSharesPoolsByAddress(t *testing.T) {
+	server1, address1 := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server1.Stop()
+	server2, address2 := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server2.Stop()
+
+	m := pool.NewManager(0, pool.WithMaxConnections(2), pool.WithBackoff(&utils.MockBackoff{}))
+	defer m.Close()
+	ctx := context.Background()
+
+	a, err := m.Get(ctx, address1)
+	utils.AssertNil(t, err, "Get should succeed")
+	b, err := m.Get(ctx, address1)
+	utils.AssertNil(t, err, "Get should succeed")
+	c, err := m.Get(ctx, address2)
+	utils.AssertNil(t, err, "Get should succeed")
+
+	stats := m.Stats()
+	utils.AssertEqual(t, 2, stats.Pools, "One pool per address should be created")
+	utils.AssertEqual(t, 3, stats.Total.InUse, "Total should sum the pools")
+	utils.AssertEqual(t, 2, stats.ByPool[pool.Target{Network: "tcp", Address: address1}.String()].InUse, "Connections should share a pool")
+
+	for _, conn := range []net.Conn{a, b, c} {
+		utils.AssertNil(t, m.Release(conn), "Release should succeed")
+	}
+	utils.AssertEqual(t, 3, m.Stats().Total.Idle, "Connections should return to their pools")
+
+	client, other := net.Pipe()
+	defer client.Close()
+	defer other.Close()
+	utils.AssertTrue(t, errors.Is(m.Release(client), pool.ErrForeignConn), "A foreign connection should be rejected")
+}
+
+func TestManagerReapsIdlePools(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	m := pool.NewManager(40*time.Millisecond, pool.WithBackoff(&utils.MockBackoff{}))
+	defer m.Close()
+
+	conn, err := m.Get(context.Background(), address)
+	utils.AssertNil(t, err, "Get should succeed")
+	time.Sleep(100 * time.Millisecond)
+	utils.AssertEqual(t, 1, m.Stats().Pools, "A pool with a connection checked out should be kept")
+
+	utils.AssertNil(t, m.Release(conn), "Release should succeed")
+	time.Sleep(150 * time.Millisecond)
+	stats := m.Stats()
+	utils.AssertEqual(t, 0, stats.Pools, "An unused pool should be reaped")
+	utils.AssertEqual(t, uint64(1), stats.Reaped, "The reaped pool should be counted")
+
+	conn, err = m.Get(context.Background(), address)
+	utils.AssertNil(t, err, "A reaped pool should be recreated on demand")
+	utils.AssertNil(t, m.Release(conn), "Release should succeed")
+}
+
+func TestManagerClose(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	m := pool.NewManager(0)
+	conn, err := m.Get(context.Background(), address)
+	utils.AssertNil(t, err, "Get should succeed")
+
+	utils.AssertNil(t, m.Close(), "Close should succeed")
+	_, err = m.Get(context.Background(), address)
+	utils.AssertTrue(t, errors.Is(err, pool.ErrPoolClosed), "Get after Close should fail")
+	utils.AssertNil(t, m.Release(conn), "Releasing after Close should close the connection")
+}
+
+func TestManagerTellsTLSConfigurationsApart(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{TLS: true})
+	defer server.Stop()
+
+	m := pool.NewManager(0, pool.WithBackoff(&utils.MockBackoff{}))
+	defer m.Close()
+	alice := pool.Target{Address: address, TLSConfig: &tls.Config{InsecureSkipVerify: true}}
+	bob := pool.Target{Address: address, TLSConfig: &tls.Config{InsecureSkipVerify: true}}
+
+	a, err := m.GetTarget(context.Background(), alice)
+	utils.AssertNil(t, err, "Get should succeed")
+	b, err := m.GetTarget(context.Background(), bob)
+	utils.AssertNil(t, err, "Get should succeed")
+
+	stats := m.Stats()
+	utils.AssertEqual(t, 2, stats.Pools, "Each TLS configuration should get its own pool")
+	utils.AssertEqual(t, 2, len(stats.ByPool), "Each pool should have its own stats")
+	utils.AssertEqual(t, 1, stats.ByPool[m.PoolID(alice)].InUse, "Stats should be keyed by pool ID")
+	utils.AssertEqual(t, 1, stats.ByPool[m.PoolID(bob)].InUse, "Stats should be keyed by pool ID")
+	m.Release(a)
+	m.Release(b)
+}
+
+func TestManagerTinyIdlePoolTimeout(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	m := pool.NewManager(time.Nanosecond, pool.WithBackoff(&utils.MockBackoff{}))
+	defer m.Close()
+	conn, err := m.Get(context.Background(), address)
+	utils.AssertNil(t, err, "Get should succeed")
+	utils.AssertNil(t, m.Release(conn), "Release should succeed")
+
+	time.Sleep(50 * time.Millisecond)
+	utils.AssertEqual(t, 0, m.Stats().Pools, "The unused pool should be reaped")
+}
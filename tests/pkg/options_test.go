@@ -0,0 +1,62 @@
+package tcppool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	dials := 0
+	p, err := pool.New(address,
+		pool.WithName("options-pool"),
+		pool.WithMaxConnections(2),
+		pool.WithBackoff(pool.NewFixedBackoff(1)),
+		pool.WithDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+			dials++
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		}),
+	)
+	utils.AssertNil(t, err, "Pool creation should not return an error")
+
+	conn, err := p.Get()
+	utils.AssertNil(t, err, "Getting a connection should not return an error")
+	utils.AssertEqual(t, 1, dials, "Custom dialer should be used")
+	utils.AssertNil(t, p.Release(conn), "Releasing a connection should not return an error")
+}
+
+func TestConfigValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		option pool.Option
+		reason string
+	}{
+		{"zero max connections", pool.WithMaxConnections(0), "max connections"},
+		{"zero idle timeout", pool.WithIdleTimeout(0), "idle timeout"},
+		{"nil backoff", pool.WithBackoff(nil), "backoff"},
+		{"zero max retries", pool.WithMaxRetries(0), "max retries"},
+	}
+
+	utils.AssertNil(t, pool.NewConfigWith("localhost:9999").Validate(), "Defaults should be valid")
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pool.NewConfigWith("localhost:9999", tc.option).Validate()
+			utils.AssertTrue(t, errors.Is(err, pool.ErrInvalidConfig), "Validate should reject the config")
+			utils.AssertTrue(t, err != nil && strings.Contains(err.Error(), tc.reason), "Error should name the setting")
+
+			p, err := pool.New("localhost:9999", tc.option)
+			utils.AssertNil(t, p, "Invalid config should not create a pool")
+			utils.AssertTrue(t, errors.Is(err, pool.ErrInvalidConfig), "New should return the validation error")
+		})
+	}
+}
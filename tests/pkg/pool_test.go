@@ -20,7 +20,7 @@ func TestPoolCreation(t *testing.T) {
 		pool.NewExponentialBackoff(1, 10),
 		pool.PoolHooks{},
 	)
-	pool, err := pool.New(*config)
+	pool, err := pool.NewWithConfig(*config)
 
 	utils.AssertNil(t, err, "Pool creation should not return an error")
 	utils.AssertNotNil(t, pool, "Pool should be successfully created")
@@ -47,7 +47,7 @@ func TestPoolGetRelease(t *testing.T) {
 		pool.NewExponentialBackoff(1, 10),
 		pool.PoolHooks{},
 	)
-	p, _ := pool.New(*config)
+	p, _ := pool.NewWithConfig(*config)
 
 	conn, err := p.Get()
 	utils.AssertNil(t, err, "Getting a connection should not return an error")
@@ -0,0 +1,148 @@
+package tcppool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/shard"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func newShardClient(t *testing.T, servers []shard.Server, cfg shard.Config) *shard.Client {
+	cfg.PoolOptions = append(cfg.PoolOptions, pool.WithMaxRetries(1), pool.WithBackoff(&utils.MockBackoff{}))
+	c, err := shard.New(servers, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestShardDistributionFollowsWeights(t *testing.T) {
+	for _, algorithm := range []shard.Algorithm{shard.Ketama, shard.Rendezvous} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			c := newShardClient(t, []shard.Server{
+				{Address: "10.0.1.1:11211", Weight: 1},
+				{Address: "10.0.1.2:11211", Weight: 3},
+			}, shard.Config{Algorithm: algorithm})
+
+			counts := make(map[string]int)
+			const keys = 20000
+			for i := 0; i < keys; i++ {
+				address, err := c.Lookup(fmt.Sprintf("key:%d", i))
+				utils.AssertNil(t, err, "Lookup should succeed")
+				counts[address]++
+			}
+			share := float64(counts["10.0.1.2:11211"]) / keys
+			// With only two servers, ketama's arcs vary by several percent around the weights.
+			utils.AssertTrue(t, math.Abs(share-0.75) < 0.1, fmt.Sprintf("The heavier server should own about 75%% of the keys, got %.3f", share))
+		})
+	}
+}
+
+func TestShardRemovingServerOnlyMovesItsKeys(t *testing.T) {
+	addresses := []string{"10.0.1.1:11211", "10.0.1.2:11211", "10.0.1.3:11211", "10.0.1.4:11211"}
+	servers := func(n int) []shard.Server {
+		var s []shard.Server
+		for _, a := range addresses[:n] {
+			s = append(s, shard.Server{Address: a})
+		}
+		return s
+	}
+
+	for _, algorithm := range []shard.Algorithm{shard.Ketama, shard.Rendezvous} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			before := newShardClient(t, servers(4), shard.Config{Algorithm: algorithm})
+			after := newShardClient(t, servers(3), shard.Config{Algorithm: algorithm})
+
+			for i := 0; i < 5000; i++ {
+				key := fmt.Sprintf("key:%d", i)
+				was, _ := before.Lookup(key)
+				now, _ := after.Lookup(key)
+				if was != addresses[3] && was != now {
+					t.Fatalf("Key %s moved from %s to %s although its server was kept", key, was, now)
+				}
+			}
+		})
+	}
+}
+
+func TestShardEjectsAndRestoresFailingServer(t *testing.T) {
+	server, live := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	dead := listener.Addr().String()
+	listener.Close()
+
+	var ejected, restored []string
+	c := newShardClient(t, []shard.Server{{Address: live}, {Address: dead}}, shard.Config{
+		EjectAfter: 2,
+		RetryAfter: 300 * time.Millisecond,
+		OnEject:    func(address string, err error) { ejected = append(ejected, address) },
+		OnRestore:  func(address string) { restored = append(restored, address) },
+	})
+
+	key := ""
+	for i := 0; key == ""; i++ {
+		if address, _ := c.Lookup(fmt.Sprint(i)); address == dead {
+			key = fmt.Sprint(i)
+		}
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(ctx, key)
+		utils.AssertNotNil(t, err, "Dialing the dead server should fail")
+	}
+	utils.AssertEqual(t, fmt.Sprint([]string{dead}), fmt.Sprint(ejected), "The server should be ejected after two failures")
+	address, _ := c.Lookup(key)
+	utils.AssertEqual(t, live, address, "The key should be rehashed onto the live server")
+
+	conn, err := c.Get(ctx, key)
+	utils.AssertNil(t, err, "Get should succeed on the live server")
+	utils.AssertNil(t, c.Release(conn), "Release should succeed")
+	utils.AssertNotNil(t, c.Release(conn), "Releasing twice should fail")
+
+	stats := c.Stats()
+	utils.AssertEqual(t, uint64(1), stats.Ejections, "Stats should count the ejection")
+	utils.AssertTrue(t, stats.Servers[1].Ejected, "Stats should report the ejected server")
+
+	time.Sleep(400 * time.Millisecond)
+	address, _ = c.Lookup(key)
+	utils.AssertEqual(t, dead, address, "The server should be retried after RetryAfter")
+	utils.AssertEqual(t, fmt.Sprint([]string{dead}), fmt.Sprint(restored), "OnRestore should be called")
+}
+
+func TestShardIgnoresFailuresOfCallers(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	errNotReady := errors.New("session not ready")
+	c := newShardClient(t, []shard.Server{{Address: address}}, shard.Config{
+		EjectAfter: 2,
+		PoolOptions: []pool.Option{pool.WithHooks(pool.PoolHooks{
+			OnBorrow: func(context.Context, net.Conn) error { return errNotReady },
+		})},
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(context.Background(), "key")
+		utils.AssertTrue(t, errors.Is(err, errNotReady), "Get should report the hook's rejection")
+	}
+	utils.AssertEqual(t, uint64(0), c.Stats().Ejections, "Rejections by hooks should not eject a server")
+}
+
+func TestShardRejectsInvalidConfig(t *testing.T) {
+	_, err := shard.New(nil, shard.Config{})
+	utils.AssertNotNil(t, err, "A client needs servers")
+	_, err = shard.New([]shard.Server{{Address: "a:1"}, {Address: "a:1"}}, shard.Config{})
+	utils.AssertNotNil(t, err, "Duplicate addresses should be rejected")
+	_, err = shard.New([]shard.Server{{Address: "a:1"}}, shard.Config{Algorithm: "modulo"})
+	utils.AssertNotNil(t, err, "Unknown algorithms should be rejected")
+}
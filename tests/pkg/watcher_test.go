@@ -0,0 +1,106 @@
+package tcppool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pool "github.com/meliadamian17/tcppool"
+	"github.com/meliadamian17/tcppool/tests/utils"
+)
+
+func TestConfigWatcherReconfiguresPools(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	path := filepath.Join(t.TempDir(), "pools.json")
+	write := func(max int) {
+		doc := fmt.Sprintf(`{"pools": {"svc": {"address": %q, "max_connections": %d}}}`, address, max)
+		utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+	}
+	write(2)
+
+	pools, err := pool.LoadPools(path)
+	utils.AssertNil(t, err, "Loading pools should not return an error")
+
+	reloaded := make(chan int, 1)
+	w := &pool.ConfigWatcher{
+		Path:     path,
+		Interval: 10 * time.Millisecond,
+		OnReload: func(name string, c *pool.Config) {
+			select {
+			case reloaded <- c.MaxConnections():
+			default:
+			}
+		},
+		OnError: func(err error) { t.Errorf("Unexpected watcher error: %v", err) },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx, pools)
+
+	time.Sleep(30 * time.Millisecond)
+	write(6)
+
+	select {
+	case max := <-reloaded:
+		utils.AssertEqual(t, 6, max, "The new settings should be applied")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pool was not reconfigured after the file changed")
+	}
+}
+
+func TestConfigWatcherKeepsHealthProbe(t *testing.T) {
+	server, address := utils.NewMockServer(t, utils.MockServerConfig{})
+	defer server.Stop()
+
+	path := filepath.Join(t.TempDir(), "pools.yaml")
+	write := func(fall int) {
+		doc := fmt.Sprintf("pools:\n  svc:\n    address: %s\n    health_check:\n      interval: 20ms\n      fall: %d\n", address, fall)
+		utils.AssertNil(t, os.WriteFile(path, []byte(doc), 0o600), "Writing the config file should succeed")
+	}
+	write(2)
+
+	var probes atomic.Int32
+	pools, err := pool.LoadPools(path, pool.WithHealthCheck(pool.HealthCheckConfig{
+		Probe: func(context.Context, net.Conn) error { probes.Add(1); return nil },
+	}))
+	utils.AssertNil(t, err, "Loading pools should not return an error")
+	defer pools["svc"].Close()
+
+	reloaded := make(chan struct{}, 1)
+	w := &pool.ConfigWatcher{
+		Path:     path,
+		Interval: 10 * time.Millisecond,
+		OnReload: func(string, *pool.Config) { reloaded <- struct{}{} },
+		OnError:  func(err error) { t.Errorf("Unexpected watcher error: %v", err) },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		w.Watch(ctx, pools)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	write(3)
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pool was not reconfigured after the file changed")
+	}
+
+	// A round already in progress may still use the old settings; the following ones must too.
+	before := probes.Load()
+	time.Sleep(150 * time.Millisecond)
+	utils.AssertTrue(t, probes.Load() > before+1, "The probe set in code should survive a reload")
+}
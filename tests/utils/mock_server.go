@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/tls"
 	"net"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ type MockServerConfig struct {
 	SendData     bool
 	Data         []byte
 	SendInterval time.Duration
+	TLS          bool // Serve TLS with a self-signed certificate; clients must skip verification
 }
 
 type MockServer struct {
@@ -23,6 +25,9 @@ func NewMockServer(t *testing.T, config MockServerConfig) (*MockServer, string)
 	if err != nil {
 		t.Fatalf("Failed to start mock server: %v", err)
 	}
+	if config.TLS {
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{NewTestCertificate(t)}})
+	}
 
 	server := &MockServer{
 		listener: listener,
@@ -57,6 +62,11 @@ func (s *MockServer) run() {
 
 func (s *MockServer) handleClient(conn net.Conn) {
 	defer conn.Close()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+	}
 
 	if s.config.SendData {
 		ticker := time.NewTicker(s.config.SendInterval)
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meliadamian17/tcppool/internal"
+)
+
+// NewTestPool creates a pool with the settings most tests share, adjusted by configure, and
+// closes it when the test ends so its background goroutines stop.
+func NewTestPool(t *testing.T, configure func(*internal.ConfigImpl)) *internal.ConnectionPool {
+	t.Helper()
+	config := internal.ConfigImpl{
+		MaxConnections: 5,
+		ConnTimeout:    2 * time.Second,
+		IdleTimeout:    10 * time.Second,
+		MaxRetries:     3,
+		Backoff:        &MockBackoff{},
+	}
+	configure(&config)
+	pool, err := internal.NewConnectionPool(config)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
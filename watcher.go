@@ -0,0 +1,98 @@
+package tcppool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often a ConfigWatcher checks its file when Interval is not set.
+const DefaultWatchInterval = 5 * time.Second
+
+// ConfigWatcher keeps running pools in sync with a configuration file read by LoadConfigs.
+// The file is polled, and every time its contents change the pools it names are reconfigured.
+// Pools missing from the file, or from the map passed to Watch, are left alone.
+type ConfigWatcher struct {
+	Path     string        // The configuration file
+	Interval time.Duration // How often the file is checked (defaults to DefaultWatchInterval)
+	Options  []Option      // Options applied before the loaded settings, as for LoadConfigs
+
+	// OnReload is called after a pool has been reconfigured.
+	OnReload func(name string, c *Config)
+	// OnError is called when the file cannot be loaded or a pool rejects its new configuration.
+	// The pool keeps its previous settings.
+	OnError func(err error)
+}
+
+// Watch polls the file until ctx is done. The contents present when Watch starts are treated as
+// already applied.
+//
+// Parameters:
+//   - ctx: Stops the watcher when done.
+//   - pools: The pools to reconfigure, keyed by their name in the file.
+//
+// Returns:
+//   - ctx.Err() once ctx is done.
+func (w *ConfigWatcher) Watch(ctx context.Context, pools map[string]*Pool) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	last, _ := os.ReadFile(w.Path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(w.Path)
+		if err != nil {
+			w.reportError(err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		w.apply(pools)
+	}
+}
+
+// apply reloads the file and reconfigures the named pools.
+func (w *ConfigWatcher) apply(pools map[string]*Pool) {
+	configs, err := LoadConfigs(w.Path, w.Options...)
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	for name, c := range configs {
+		p, ok := pools[name]
+		if !ok {
+			continue
+		}
+		if err := p.Reconfigure(*c); err != nil {
+			w.reportError(fmt.Errorf("pool %q: %w", name, err))
+			continue
+		}
+		if w.OnReload != nil {
+			w.OnReload(name, c)
+		} else {
+			fmt.Printf("Reconfigured pool %q from %s\n", name, w.Path)
+		}
+	}
+}
+
+// reportError forwards an error to OnError, or logs it.
+func (w *ConfigWatcher) reportError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+		return
+	}
+	fmt.Printf("Failed to reload configuration from %s: %v\n", w.Path, err)
+}